@@ -0,0 +1,198 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/archive"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestExtract(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("extraction of links and modes is not tested on windows")
+	}
+	spec.Run(t, "Extract", testExtract, spec.Report(report.Terminal{}))
+}
+
+type tarEntry struct {
+	hdr     tar.Header
+	content string
+}
+
+func tarOf(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		hdr := entry.hdr
+		hdr.Size = int64(len(entry.content))
+		h.AssertNil(t, tw.WriteHeader(&hdr))
+		_, err := tw.Write([]byte(entry.content))
+		h.AssertNil(t, err)
+	}
+	h.AssertNil(t, tw.Close())
+	return &buf
+}
+
+func testExtract(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir, dest string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "extract-test")
+		h.AssertNil(t, err)
+		dest = filepath.Join(tmpDir, "dest")
+		h.AssertNil(t, os.Mkdir(dest, 0755))
+	})
+
+	it.After(func() {
+		filepath.Walk(tmpDir, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && fi.IsDir() {
+				os.Chmod(path, 0755)
+			}
+			return nil
+		})
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#ExtractTar", func() {
+		it("extracts absolute names relative to the destination", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "/buildpacks/", Typeflag: tar.TypeDir, Mode: 0755}},
+				tarEntry{hdr: tar.Header{Name: "/buildpacks/bin/detect", Typeflag: tar.TypeReg, Mode: 0755}, content: "detect"},
+			), dest)
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, dest, "buildpacks/bin/detect", "detect")
+		})
+
+		it("rejects entries that escape the destination", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "some-dir/../../escaped", Typeflag: tar.TypeReg, Mode: 0644}, content: "escaped"},
+			), dest)
+			h.AssertError(t, err, `invalid tar entry "some-dir/../../escaped": path escapes the destination`)
+
+			_, err = os.Stat(filepath.Join(tmpDir, "escaped"))
+			h.AssertEq(t, os.IsNotExist(err), true)
+		})
+
+		it("does not write through symlinks", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: tmpDir}},
+				tarEntry{hdr: tar.Header{Name: "link/escaped", Typeflag: tar.TypeReg, Mode: 0644}, content: "escaped"},
+			), dest)
+			h.AssertError(t, err, `invalid tar entry "link/escaped": parent`)
+
+			_, err = os.Stat(filepath.Join(tmpDir, "escaped"))
+			h.AssertEq(t, os.IsNotExist(err), true)
+		})
+
+		it("replaces a symlink with a file rather than writing to its target", func() {
+			target := filepath.Join(tmpDir, "target")
+			h.AssertNil(t, ioutil.WriteFile(target, []byte("original"), 0644))
+
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "file", Typeflag: tar.TypeSymlink, Linkname: target}},
+				tarEntry{hdr: tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0644}, content: "replaced"},
+			), dest)
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, tmpDir, "target", "original")
+			h.AssertDirContainsFileWithContents(t, dest, "file", "replaced")
+		})
+
+		it("extracts hard links to files inside the destination", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "some-file", Typeflag: tar.TypeReg, Mode: 0644}, content: "some-content"},
+				tarEntry{hdr: tar.Header{Name: "some-link", Typeflag: tar.TypeLink, Linkname: "some-file"}},
+			), dest)
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, dest, "some-link", "some-content")
+
+			fileInfo, err := os.Stat(filepath.Join(dest, "some-file"))
+			h.AssertNil(t, err)
+			linkInfo, err := os.Stat(filepath.Join(dest, "some-link"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, os.SameFile(fileInfo, linkInfo), true)
+		})
+
+		it("rejects hard links to files outside the destination", func() {
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "outside"), []byte("outside"), 0644))
+
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "some-link", Typeflag: tar.TypeLink, Linkname: "../outside"}},
+			), dest)
+			h.AssertError(t, err, `invalid tar entry "../outside": path escapes the destination`)
+		})
+
+		it("skips device nodes and FIFOs", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "some-fifo", Typeflag: tar.TypeFifo, Mode: 0644}},
+				tarEntry{hdr: tar.Header{Name: "some-device", Typeflag: tar.TypeChar, Mode: 0644, Devmajor: 1, Devminor: 3}},
+				tarEntry{hdr: tar.Header{Name: "some-file", Typeflag: tar.TypeReg, Mode: 0644}, content: "some-content"},
+			), dest)
+			h.AssertNil(t, err)
+
+			entries, err := ioutil.ReadDir(dest)
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 1)
+		})
+
+		it("preserves modes, applying directory modes after their contents", func() {
+			err := archive.ExtractTar(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "read-only/", Typeflag: tar.TypeDir, Mode: 0555}},
+				tarEntry{hdr: tar.Header{Name: "read-only/script", Typeflag: tar.TypeReg, Mode: 0750}, content: "script"},
+			), dest)
+			h.AssertNil(t, err)
+
+			dirInfo, err := os.Stat(filepath.Join(dest, "read-only"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, dirInfo.Mode().Perm(), os.FileMode(0555))
+			fileInfo, err := os.Stat(filepath.Join(dest, "read-only", "script"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, fileInfo.Mode().Perm(), os.FileMode(0750))
+		})
+	})
+
+	when("#ExtractTarWithOptions", func() {
+		it("normalizes modes unless asked to preserve them", func() {
+			err := archive.ExtractTarWithOptions(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "script", Typeflag: tar.TypeReg, Mode: 0700}, content: "script"},
+				tarEntry{hdr: tar.Header{Name: "data", Typeflag: tar.TypeReg, Mode: 0600}, content: "data"},
+			), dest, archive.ExtractOptions{})
+			h.AssertNil(t, err)
+
+			scriptInfo, err := os.Stat(filepath.Join(dest, "script"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, scriptInfo.Mode().Perm(), os.FileMode(0755))
+			dataInfo, err := os.Stat(filepath.Join(dest, "data"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, dataInfo.Mode().Perm(), os.FileMode(0644))
+		})
+
+		it("preserves modification times when asked", func() {
+			modTime := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+			err := archive.ExtractTarWithOptions(tarOf(t,
+				tarEntry{hdr: tar.Header{Name: "some-dir/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime}},
+				tarEntry{hdr: tar.Header{Name: "some-dir/some-file", Typeflag: tar.TypeReg, Mode: 0644, ModTime: modTime}, content: "some-content"},
+			), dest, archive.ExtractOptions{PreserveModTimes: true})
+			h.AssertNil(t, err)
+
+			for _, path := range []string{"some-dir", filepath.Join("some-dir", "some-file")} {
+				info, err := os.Stat(filepath.Join(dest, path))
+				h.AssertNil(t, err)
+				h.AssertEq(t, info.ModTime().UTC(), modTime)
+			}
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manifest maps the slash-separated path of every entry below a directory to a digest of its type, mode and content
+type Manifest map[string]string
+
+func CreateManifest(srcDir string) (Manifest, error) {
+	manifest := Manifest{}
+	err := filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcDir, file)
+		if err != nil {
+			return err
+		} else if relPath == "." {
+			return nil
+		}
+
+		digest, err := entryDigest(file, fi)
+		if err != nil {
+			return err
+		}
+		manifest[filepath.ToSlash(relPath)] = digest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func entryDigest(file string, fi os.FileInfo) (string, error) {
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		return "symlink:" + target, nil
+	case fi.IsDir():
+		return fmt.Sprintf("dir:%o", fi.Mode().Perm()), nil
+	default:
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("file:%o:%x", fi.Mode().Perm(), hash.Sum(nil)), nil
+	}
+}
+
+// Diff compares the manifest with a previous one. It returns the entries that are new or changed, and the entries that
+// must be removed first because they no longer exist or changed type. Removed paths are returned outermost first,
+// omitting entries below a directory that is itself removed.
+func (m Manifest) Diff(previous Manifest) (changed, removed []string) {
+	for path, digest := range m {
+		prev, ok := previous[path]
+		if ok && prev == digest {
+			continue
+		}
+		changed = append(changed, path)
+		if ok && entryType(prev) != entryType(digest) {
+			removed = append(removed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := m[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+
+	isRemoved := map[string]bool{}
+	for _, path := range removed {
+		isRemoved[path] = true
+	}
+	var outermost []string
+	for _, path := range removed {
+		if !hasRemovedParent(path, isRemoved) {
+			outermost = append(outermost, path)
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Strings(outermost)
+	return changed, outermost
+}
+
+func hasRemovedParent(path string, isRemoved map[string]bool) bool {
+	for dir := pathpkg.Dir(path); dir != "."; dir = pathpkg.Dir(dir) {
+		if isRemoved[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+func entryType(digest string) string {
+	return strings.SplitN(digest, ":", 2)[0]
+}
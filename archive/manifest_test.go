@@ -0,0 +1,104 @@
+package archive_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/archive"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestManifest(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Manifest", testManifest, spec.Report(report.Terminal{}))
+}
+
+func testManifest(t *testing.T, when spec.G, it spec.S) {
+	var srcDir string
+
+	it.Before(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "manifest-test")
+		h.AssertNil(t, err)
+		h.AssertNil(t, os.MkdirAll(filepath.Join(srcDir, "sub-dir"), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(srcDir, "some-file.txt"), []byte("some-content"), 0644))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(srcDir, "sub-dir", "other-file.txt"), []byte("other-content"), 0644))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(srcDir))
+	})
+
+	when("#CreateManifest", func() {
+		it("records every entry by relative path", func() {
+			manifest, err := archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(manifest), 3)
+			h.AssertContains(t, manifest["some-file.txt"], "file:644:")
+			h.AssertContains(t, manifest["sub-dir/other-file.txt"], "file:644:")
+			h.AssertEq(t, manifest["sub-dir"], "dir:755")
+		})
+	})
+
+	when("#Diff", func() {
+		var previous archive.Manifest
+
+		it.Before(func() {
+			var err error
+			previous, err = archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+		})
+
+		it("returns everything as changed without a previous manifest", func() {
+			changed, removed := previous.Diff(nil)
+			h.AssertEq(t, changed, []string{"some-file.txt", "sub-dir", "sub-dir/other-file.txt"})
+			h.AssertEq(t, len(removed), 0)
+		})
+
+		it("returns nothing when the contents are unchanged", func() {
+			current, err := archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+			changed, removed := current.Diff(previous)
+			h.AssertEq(t, len(changed), 0)
+			h.AssertEq(t, len(removed), 0)
+		})
+
+		it("returns modified and added files as changed", func() {
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(srcDir, "some-file.txt"), []byte("new-content"), 0644))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(srcDir, "sub-dir", "new-file.txt"), []byte("new-content"), 0644))
+
+			current, err := archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+			changed, removed := current.Diff(previous)
+			h.AssertEq(t, changed, []string{"some-file.txt", "sub-dir/new-file.txt"})
+			h.AssertEq(t, len(removed), 0)
+		})
+
+		it("returns only the outermost removed entries", func() {
+			h.AssertNil(t, os.RemoveAll(filepath.Join(srcDir, "sub-dir")))
+
+			current, err := archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+			changed, removed := current.Diff(previous)
+			h.AssertEq(t, len(changed), 0)
+			h.AssertEq(t, removed, []string{"sub-dir"})
+		})
+
+		it("removes and re-adds entries that changed type", func() {
+			h.AssertNil(t, os.RemoveAll(filepath.Join(srcDir, "sub-dir")))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(srcDir, "sub-dir"), []byte("now-a-file"), 0644))
+
+			current, err := archive.CreateManifest(srcDir)
+			h.AssertNil(t, err)
+			changed, removed := current.Diff(previous)
+			h.AssertEq(t, changed, []string{"sub-dir"})
+			h.AssertEq(t, removed, []string{"sub-dir"})
+		})
+	})
+}
@@ -26,15 +26,47 @@ func CreateTar(tarFile, srcDir, tarDir string, uid, gid int) error {
 		return fmt.Errorf("create file for tar: %s", err)
 	}
 	defer fh.Close()
-	return writeTarArchive(fh, srcDir, tarDir, uid, gid)
+	return writeTarArchive(fh, srcDir, tarDir, uid, gid, nil, false)
+}
+
+// CreateTarGZ writes a gzipped tar of the contents of srcDir with its entries at the root of the archive. Besides the
+// normalized timestamps and ownership of CreateTar, modes are normalized to 0755 for directories and executables and
+// 0644 for other files, so that the archive only depends on the contents of srcDir.
+func CreateTarGZ(tarFile, srcDir string, uid, gid int) error {
+	return CreateFilteredTarGZ(tarFile, srcDir, uid, gid, nil)
+}
+
+// CreateFilteredTarGZ is like CreateTarGZ, but only includes the entries whose slash-separated path relative to srcDir
+// is accepted by include
+func CreateFilteredTarGZ(tarFile, srcDir string, uid, gid int, include func(path string) bool) error {
+	fh, err := os.Create(tarFile)
+	if err != nil {
+		return fmt.Errorf("create file for tar: %s", err)
+	}
+	defer fh.Close()
+
+	gzw := gzip.NewWriter(fh)
+	if err := writeTarArchive(gzw, srcDir, "", uid, gid, include, true); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	return fh.Close()
 }
 
 func CreateTarReader(srcDir, tarDir string, uid, gid int) (io.Reader, chan error) {
+	return CreateFilteredTarReader(srcDir, tarDir, uid, gid, nil)
+}
+
+// CreateFilteredTarReader is like CreateTarReader, but only includes the entries whose slash-separated path relative
+// to srcDir is accepted by include
+func CreateFilteredTarReader(srcDir, tarDir string, uid, gid int, include func(path string) bool) (io.Reader, chan error) {
 	r, w := io.Pipe()
 	errChan := make(chan error, 1)
 	go func() {
 		defer w.Close()
-		err := writeTarArchive(w, srcDir, tarDir, uid, gid)
+		err := writeTarArchive(w, srcDir, tarDir, uid, gid, include, false)
 		w.Close()
 		errChan <- err
 	}()
@@ -77,50 +109,206 @@ func CreateSingleFileTarReader(path, txt string) (io.Reader, error) {
 	return bytes.NewReader(buf.Bytes()), nil
 }
 
+// ExtractOptions controls which metadata from the tar headers is restored on the extracted files
+type ExtractOptions struct {
+	// PreserveModes applies the permission bits from the headers exactly, rather than the defaults of 0755 for
+	// directories and executables and 0644 for other files
+	PreserveModes bool
+	// PreserveModTimes applies the modification times from the headers to files and directories
+	PreserveModTimes bool
+}
+
+// ExtractTar extracts a tar into dest, preserving the modes of the entries
 func ExtractTar(r io.Reader, dest string) error {
+	return ExtractTarWithOptions(r, dest, ExtractOptions{PreserveModes: true})
+}
+
+// ExtractTarWithOptions extracts a tar into dest. Entry names are always resolved inside dest: absolute names are taken
+// as relative to dest, and entries that would escape it through ".." or through a symlink extracted earlier are
+// rejected. Device nodes and FIFOs are skipped.
+func ExtractTarWithOptions(r io.Reader, dest string, opts ExtractOptions) error {
+	type dirHeader struct {
+		path string
+		hdr  *tar.Header
+	}
+	var dirs []dirHeader
+
 	tr := tar.NewReader(r)
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
-			return nil
+			break
 		} else if err != nil {
 			return err
 		}
 
-		path := filepath.Join(dest, hdr.Name)
+		path, err := extractPath(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if path == filepath.Clean(dest) && hdr.Typeflag != tar.TypeDir {
+			return fmt.Errorf("invalid tar entry %q: cannot replace the destination", hdr.Name)
+		}
+		if err := checkNoSymlinkParents(dest, path); err != nil {
+			return errors.Wrapf(err, "invalid tar entry %q", hdr.Name)
+		}
 
 		switch hdr.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(path, hdr.FileInfo().Mode()); err != nil {
+			if err := extractDir(path); err != nil {
 				return err
 			}
+			dirs = append(dirs, dirHeader{path: path, hdr: hdr})
+			continue
 		case tar.TypeReg, tar.TypeRegA:
-			_, err := os.Stat(filepath.Dir(path))
-			if os.IsNotExist(err) {
-				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-					return err
-				}
+			if err := extractFile(path, tr); err != nil {
+				return err
 			}
-
-			fh, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, hdr.FileInfo().Mode())
+		case tar.TypeSymlink:
+			if err := prepareEntry(path); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return err
+			}
+			continue
+		case tar.TypeLink:
+			target, err := extractPath(dest, hdr.Linkname)
 			if err != nil {
 				return err
 			}
-
-			if _, err := io.Copy(fh, tr); err != nil {
-				fh.Close()
+			if err := checkNoSymlinkParents(dest, target); err != nil {
+				return errors.Wrapf(err, "invalid tar entry %q", hdr.Name)
+			}
+			if fi, err := os.Lstat(target); err != nil {
+				return errors.Wrapf(err, "invalid hard link %q", hdr.Name)
+			} else if !fi.Mode().IsRegular() {
+				return fmt.Errorf("invalid hard link %q: target %q is not a regular file", hdr.Name, hdr.Linkname)
+			}
+			if err := prepareEntry(path); err != nil {
 				return err
 			}
-
-			fh.Close()
-		case tar.TypeSymlink:
-			if err := os.Symlink(hdr.Linkname, path); err != nil {
+			if err := os.Link(target, path); err != nil {
 				return err
 			}
+			// the link shares its inode, and so its mode and times, with the target
+			continue
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			continue
 		default:
 			return fmt.Errorf("unknown file type in tar %d", hdr.Typeflag)
 		}
+
+		if err := applyMetadata(path, hdr, opts); err != nil {
+			return err
+		}
+	}
+
+	// Directories are finished last, so that read-only modes and modification times are not disturbed by their contents
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := applyMetadata(dirs[i].path, dirs[i].hdr, opts); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// extractPath returns where an entry is extracted inside dest, or an error if its name escapes dest
+func extractPath(dest, name string) (string, error) {
+	rel := filepath.Clean(strings.TrimLeft(filepath.FromSlash(name), string(filepath.Separator)))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("invalid tar entry %q: path escapes the destination", name)
+	}
+	return filepath.Join(dest, rel), nil
+}
+
+// checkNoSymlinkParents ensures that no directory between dest and path is a symlink, so that writing path cannot
+// modify anything outside dest
+func checkNoSymlinkParents(dest, path string) error {
+	rel, err := filepath.Rel(dest, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return err
+	}
+
+	current := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("parent %q is a symlink", current)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("parent %q is not a directory", current)
+		}
+	}
+	return nil
+}
+
+// prepareEntry creates the parent directories of path and removes any file, link or empty directory already there
+func prepareEntry(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func extractDir(path string) error {
+	fi, err := os.Lstat(path)
+	if err == nil && fi.IsDir() {
+		return nil
+	} else if err == nil || !os.IsNotExist(err) {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+func extractFile(path string, r io.Reader) error {
+	if err := prepareEntry(path); err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fh, r); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+func applyMetadata(path string, hdr *tar.Header, opts ExtractOptions) error {
+	mode := hdr.FileInfo().Mode().Perm()
+	if !opts.PreserveModes {
+		mode = 0644
+		if hdr.Typeflag == tar.TypeDir || hdr.FileInfo().Mode()&0111 != 0 {
+			mode = 0755
+		}
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+
+	if opts.PreserveModTimes && !hdr.ModTime.IsZero() {
+		accessTime := hdr.AccessTime
+		if accessTime.IsZero() {
+			accessTime = hdr.ModTime
+		}
+		if err := os.Chtimes(path, accessTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func ExtractTarGZ(r io.Reader, dest string) error {
@@ -163,13 +351,14 @@ func isNotRootDir(parent string) bool {
 	return parent != "/"
 }
 
-func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
+func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int, include func(string) bool, normalizeModes bool) error {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
-	err := writeParentDirectoryHeaders(tarDir, tw, uid, gid)
-	if err != nil {
-		return err
+	if tarDir != "" {
+		if err := writeParentDirectoryHeaders(tarDir, tw, uid, gid); err != nil {
+			return err
+		}
 	}
 
 	return filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
@@ -201,6 +390,9 @@ func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
 		} else if relPath == "." {
 			return nil
 		}
+		if include != nil && !include(filepath.ToSlash(relPath)) {
+			return nil
+		}
 
 		header.Name = filepath.Join(tarDir, relPath)
 		if runtime.GOOS == "windows" {
@@ -211,6 +403,14 @@ func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
 		header.Gid = gid
 		header.Uname = ""
 		header.Gname = ""
+		if normalizeModes && fi.Mode()&os.ModeSymlink == 0 {
+			header.Mode = 0644
+			if fi.IsDir() || fi.Mode()&0111 != 0 {
+				header.Mode = 0755
+			}
+			header.AccessTime = time.Time{}
+			header.ChangeTime = time.Time{}
+		}
 
 		if err := tw.WriteHeader(header); err != nil {
 			return err
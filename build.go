@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/buildpack/pack/build"
 	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/cache"
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/docker"
@@ -29,32 +31,38 @@ type Cache interface {
 }
 
 type BuildFactory struct {
-	Cli     Docker
-	Logger  *logging.Logger
-	Config  *config.Config
-	Cache   Cache
-	Fetcher Fetcher
+	Cli              Docker
+	Logger           *logging.Logger
+	Config           *config.Config
+	Cache            Cache
+	Fetcher          Fetcher
+	BuildpackFetcher BuildpackFetcher
 }
 
 type BuildFlags struct {
-	AppDir     string
-	Builder    string
-	RunImage   string
-	Env        []string
-	EnvFile    string
-	RepoName   string
-	Publish    bool
-	NoPull     bool
-	ClearCache bool
-	Buildpacks []string
+	AppDir          string
+	Builder         string
+	RunImage        string
+	Env             []string
+	EnvFile         string
+	RepoName        string
+	Publish         bool
+	NoPull          bool
+	ClearCache      bool
+	Buildpacks      []string
+	Order           string
+	LifecycleImage  string
+	SingleContainer bool
+	ReuseAppVolume  bool
 }
 
 type BuildConfig struct {
-	Builder    string
-	RunImage   string
-	RepoName   string
-	Publish    bool
-	ClearCache bool
+	Builder         string
+	RunImage        string
+	RepoName        string
+	Publish         bool
+	ClearCache      bool
+	SingleContainer bool
 	// Above are copied from BuildFlags are set by init
 	Cli    Docker
 	Logger *logging.Logger
@@ -78,6 +86,12 @@ func DefaultBuildFactory(logger *logging.Logger, cache Cache, dockerClient Docke
 	if err != nil {
 		return nil, err
 	}
+	bpFetcher := buildpack.NewFetcher(logger, f.Config.Path())
+	bpFetcher.ImageFetcher = fetcher
+	if err := bpFetcher.ConfigureDownloads(f.Config.Downloads); err != nil {
+		return nil, err
+	}
+	f.BuildpackFetcher = bpFetcher
 
 	return f, nil
 }
@@ -106,6 +120,10 @@ func calculateRepositoryName(appDir string, buildFlags *BuildFlags) string {
 	return buildFlags.RepoName
 }
 
+func appVolumeName(appDir string) string {
+	return fmt.Sprintf("pack-app-%x", md5.Sum([]byte(appDir)))
+}
+
 func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags) (*BuildConfig, error) {
 	var (
 		err          error
@@ -127,12 +145,13 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 	f.RepoName = calculateRepositoryName(appDir, f)
 
 	b := &BuildConfig{
-		RepoName:   f.RepoName,
-		Publish:    f.Publish,
-		ClearCache: f.ClearCache,
-		Cli:        bf.Cli,
-		Logger:     bf.Logger,
-		Config:     bf.Config,
+		RepoName:        f.RepoName,
+		Publish:         f.Publish,
+		ClearCache:      f.ClearCache,
+		SingleContainer: f.SingleContainer,
+		Cli:             bf.Cli,
+		Logger:          bf.Logger,
+		Config:          bf.Config,
 	}
 
 	var env map[string]string
@@ -148,6 +167,26 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 		env = addEnvVar(env, item)
 	}
 
+	var order *build.Order
+	if f.Order != "" {
+		if len(f.Buildpacks) != 0 {
+			return nil, errors.New("--buildpack and --order cannot be used together")
+		}
+		o, err := build.ReadOrder(f.Order)
+		if err != nil {
+			return nil, err
+		}
+		order = &o
+	} else if len(f.Buildpacks) == 0 {
+		order, err = build.ReadProjectOrder(appDir)
+		if err != nil {
+			return nil, err
+		}
+		if order != nil {
+			bf.Logger.Verbose("Using order from %s (use --order or --buildpack to override)", style.Symbol(build.ProjectDescriptorName))
+		}
+	}
+
 	if f.Builder == "" {
 		bf.Logger.Verbose("Using default builder image %s", style.Symbol(bf.Config.DefaultBuilder))
 		b.Builder = bf.Config.DefaultBuilder
@@ -171,6 +210,15 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 		builderImage = builder.NewBuilder(img, bf.Config)
 	}
 
+	buildpacks, err := bf.fetchBuildpacks(f.Buildpacks)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBuildpackRefs(builderImage, b.Builder, buildpacks, order); err != nil {
+		return nil, err
+	}
+
 	if f.RunImage != "" {
 		bf.Logger.Verbose("Using user-provided run image %s", style.Symbol(f.RunImage))
 		b.RunImage = f.RunImage
@@ -216,20 +264,164 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 		}
 	}
 
+	lifecycleImage := f.LifecycleImage
+	if lifecycleImage != "" {
+		bf.Logger.Verbose("Using user-provided lifecycle image %s", style.Symbol(lifecycleImage))
+	} else {
+		lifecycleImage, err = builderImage.GetLifecycleImage()
+		if err != nil {
+			return nil, err
+		}
+		if lifecycleImage != "" {
+			bf.Logger.Verbose("Using lifecycle image %s from builder %s", style.Symbol(lifecycleImage), style.Symbol(b.Builder))
+		}
+	}
+	if lifecycleImage != "" {
+		if err := bf.fetchLifecycleImage(ctx, lifecycleImage, f.NoPull); err != nil {
+			return nil, err
+		}
+	}
+
+	builderEnv, err := builderImage.GetBuildEnv()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range builderEnv {
+		if _, ok := env[key]; !ok {
+			env[key] = value
+		}
+	}
+
 	b.Cache = bf.Cache
 	bf.Logger.Verbose(fmt.Sprintf("Using cache image %s", style.Symbol(b.Cache.Image())))
 
 	b.LifecycleConfig = build.LifecycleConfig{
-		BuilderImage: b.Builder,
-		Logger:       b.Logger,
-		Buildpacks:   f.Buildpacks,
-		Env:          env,
-		AppDir:       appDir,
+		BuilderImage:   b.Builder,
+		Logger:         b.Logger,
+		Buildpacks:     buildpacks,
+		Order:          order,
+		Env:            env,
+		AppDir:         appDir,
+		LifecycleImage: lifecycleImage,
+	}
+	if f.ReuseAppVolume {
+		b.LifecycleConfig.AppVolume = appVolumeName(appDir)
+		b.LifecycleConfig.AppVolumeManifest = filepath.Join(bf.Config.Path(), "app-volumes", b.LifecycleConfig.AppVolume+".json")
+		bf.Logger.Verbose("Using app volume %s", style.Symbol(b.LifecycleConfig.AppVolume))
 	}
 
 	return b, nil
 }
 
+// fetchBuildpacks downloads or extracts the buildpacks given as URLs or archives, replacing them with the local
+// directories they were fetched into. Buildpack directories and IDs are returned unchanged.
+func (bf *BuildFactory) fetchBuildpacks(buildpacks []string) ([]string, error) {
+	var fetched []string
+	for _, bp := range buildpacks {
+		if !isBuildpackArchive(bp) {
+			fetched = append(fetched, bp)
+			continue
+		}
+
+		if bf.BuildpackFetcher == nil {
+			return nil, fmt.Errorf("cannot fetch buildpack %s", style.Symbol(bp))
+		}
+		bf.Logger.Verbose("Fetching buildpack %s", style.Symbol(bp))
+		out, err := bf.BuildpackFetcher.FetchBuildpack(".", buildpack.Buildpack{URI: bp})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch buildpack %s", style.Symbol(bp))
+		}
+		if _, err := os.Stat(filepath.Join(out.Dir, "buildpack.toml")); err != nil {
+			return nil, fmt.Errorf("buildpack %s does not contain a buildpack.toml", style.Symbol(bp))
+		}
+		fetched = append(fetched, out.Dir)
+	}
+	return fetched, nil
+}
+
+// isBuildpackArchive reports whether a --buildpack value refers to a buildpack that must be fetched before use
+func isBuildpackArchive(bp string) bool {
+	if buildpack.IsImageURI(bp) {
+		return true
+	}
+	u, err := url.Parse(bp)
+	if err != nil {
+		return filepath.Ext(bp) == ".tgz"
+	}
+	switch u.Scheme {
+	case "http", "https", "file":
+		return true
+	}
+	return filepath.Ext(u.Path) == ".tgz"
+}
+
+// validateBuildpackRefs checks that the buildpacks requested by ID exist in the builder, so that mistakes are reported
+// before any container runs
+func validateBuildpackRefs(builderImage *builder.Builder, builderName string, buildpacks []string, order *build.Order) error {
+	var refs []string
+	for _, bp := range buildpacks {
+		if _, err := os.Stat(filepath.Join(bp, "buildpack.toml")); os.IsNotExist(err) {
+			refs = append(refs, bp)
+		}
+	}
+	if order != nil {
+		for _, group := range order.Groups {
+			for _, bp := range group.Buildpacks {
+				if bp.ID == "" {
+					continue
+				}
+				if bp.Version != "" {
+					refs = append(refs, bp.ID+"@"+bp.Version)
+				} else {
+					refs = append(refs, bp.ID)
+				}
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	// builders without metadata do not list their buildpacks, so the lifecycle is left to report unknown ones
+	metadata, err := builderImage.GetOptionalMetadata()
+	if err != nil || metadata == nil {
+		return err
+	}
+	for _, ref := range refs {
+		id, version := ref, ""
+		if parts := strings.SplitN(ref, "@", 2); len(parts) == 2 {
+			id, version = parts[0], parts[1]
+		}
+		if _, err := metadata.FindBuildpack(id, version); err != nil {
+			return errors.Wrapf(err, "invalid buildpack for builder %s", style.Symbol(builderName))
+		}
+	}
+	return nil
+}
+
+func (bf *BuildFactory) fetchLifecycleImage(ctx context.Context, name string, noPull bool) error {
+	var (
+		img lcimg.Image
+		err error
+	)
+	if !noPull {
+		bf.Logger.Verbose("Pulling lifecycle image %s (use --no-pull flag to skip this step)", style.Symbol(name))
+		img, err = bf.Fetcher.FetchUpdatedLocalImage(ctx, name, bf.Logger.RawVerboseWriter())
+	} else {
+		img, err = bf.Fetcher.FetchLocalImage(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if found, err := img.Found(); err != nil {
+		return fmt.Errorf("invalid lifecycle image %s: %s", style.Symbol(name), err)
+	} else if !found {
+		return fmt.Errorf("lifecycle image %s does not exist", style.Symbol(name))
+	}
+	return nil
+}
+
 func Build(ctx context.Context, outWriter, errWriter io.Writer, appDir, builderImage, runImage, repoName string, publish, clearCache bool) error {
 	// TODO: Receive Cache as an argument of this function
 	dockerClient, err := docker.New()
@@ -281,6 +473,14 @@ func (b *BuildConfig) Run(ctx context.Context) error {
 	}
 	defer lifecycle.Cleanup()
 
+	if b.SingleContainer {
+		b.Logger.Verbose(style.Step("CREATING"))
+		if b.ClearCache {
+			b.Logger.Verbose("Skipping 'restore' and 'analyze' due to clearing cache")
+		}
+		return b.create(ctx, lifecycle)
+	}
+
 	b.Logger.Verbose(style.Step("DETECTING"))
 	if err := b.detect(ctx, lifecycle); err != nil {
 		return err
@@ -320,6 +520,15 @@ func (b *BuildConfig) Run(ctx context.Context) error {
 	return nil
 }
 
+func (b *BuildConfig) create(ctx context.Context, lifecycle *build.Lifecycle) error {
+	create, err := lifecycle.NewCreate(b.RepoName, b.RunImage, b.Cache.Image(), b.Publish, b.ClearCache)
+	if err != nil {
+		return err
+	}
+	defer create.Cleanup()
+	return create.Run(ctx)
+}
+
 func (b *BuildConfig) detect(ctx context.Context, lifecycle *build.Lifecycle) error {
 	detect, err := lifecycle.NewDetect()
 	if err != nil {
@@ -0,0 +1,260 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+// appVolumeManifest records the app contents last synced into a persistent app volume
+type appVolumeManifest struct {
+	VolumeCreatedAt string           `json:"volumeCreatedAt"`
+	UID             int              `json:"uid"`
+	GID             int              `json:"gid"`
+	Entries         archive.Manifest `json:"entries"`
+}
+
+const (
+	appSourceDir       = "/pack-app-source"
+	appVolumeLockWait  = 10 * time.Minute
+	appVolumeLockRetry = 500 * time.Millisecond
+)
+
+// populateFromAppVolume syncs the persistent app volume of c with its app directory and copies it into appVolume, which
+// the build runs on
+func populateFromAppVolume(client *docker.Client, c LifecycleConfig, appVolume string, uid, gid int) (err error) {
+	unlock, err := lockAppVolume(c.AppVolumeManifest, c.Logger)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	defer func() {
+		if err != nil {
+			client.VolumeRemove(context.Background(), appVolume, true)
+		}
+	}()
+	if err := syncAppVolume(client, c.BuilderImage, c.AppVolume, c.AppDir, c.AppVolumeManifest, uid, gid, c.Logger); err != nil {
+		return err
+	}
+	return copyAppVolume(client, c.BuilderImage, c.AppVolume, appVolume, c.Logger)
+}
+
+// lockAppVolume keeps other builds of the same app from syncing or copying the persistent app volume until the
+// returned function is called. The lock is a file next to the manifest that holds the ID of the process owning it, and
+// is taken over when that process is no longer running.
+func lockAppVolume(manifestPath string, logger *logging.Logger) (func(), error) {
+	lockPath := manifestPath + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(appVolumeLockWait)
+	for waiting := false; ; waiting = true {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, err
+			}
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "failed to lock app volume")
+		}
+		if pid, ok := lockOwner(lockPath); ok && !processExists(pid) {
+			// the build holding the lock is gone, so take the lock over
+			logger.Verbose("Removing app volume lock left by build %d, which is no longer running", pid)
+			if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "failed to remove stale app volume lock")
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("app volume is locked by another build, remove %s if no build is running", style.Symbol(lockPath))
+		}
+		if !waiting {
+			logger.Info("Waiting for another build of this app to release the app volume")
+		}
+		time.Sleep(appVolumeLockRetry)
+	}
+}
+
+// lockOwner returns the PID of the build holding the lock at lockPath
+func lockOwner(lockPath string) (int, bool) {
+	contents, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(string(contents))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds for running processes on windows
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// copyAppVolume fills the volume that a build runs on with the contents of a persistent app volume, so that nothing the
+// build writes to or deletes from the app directory ends up in the persistent volume
+func copyAppVolume(client *docker.Client, builderImage, sourceVolume, targetVolume string, logger *logging.Logger) error {
+	ctx := context.Background()
+	ctr, err := client.ContainerCreate(ctx, &container.Config{
+		Image:  builderImage,
+		User:   "root",
+		Cmd:    []string{"cp", "-a", appSourceDir + "/.", appDir + "/"},
+		Labels: map[string]string{"author": "pack"},
+	}, &container.HostConfig{
+		Binds: []string{
+			fmt.Sprintf("%s:%s:ro", sourceVolume, appSourceDir),
+			fmt.Sprintf("%s:%s:", targetVolume, appDir),
+		},
+	}, nil, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create container to copy app volume %s", style.Symbol(sourceVolume))
+	}
+	defer client.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true})
+
+	if err := client.RunContainer(ctx, ctr.ID, logger.VerboseWriter().WithPrefix("copy"), logger.VerboseErrorWriter().WithPrefix("copy")); err != nil {
+		return errors.Wrapf(err, "failed to copy app volume %s", style.Symbol(sourceVolume))
+	}
+	return nil
+}
+
+// syncAppVolume brings a persistent app volume up to date with srcDir, copying only the entries that changed since the
+// last sync and removing the ones that no longer exist. Without a usable manifest the volume is recreated from scratch.
+// Builds never run on the persistent volume itself, so the manifest always describes what it contains.
+func syncAppVolume(client *docker.Client, builderImage, volumeName, srcDir, manifestPath string, uid, gid int, logger *logging.Logger) error {
+	ctx := context.Background()
+
+	current, err := archive.CreateManifest(srcDir)
+	if err != nil {
+		return errors.Wrapf(err, "reading app directory %s", style.Symbol(srcDir))
+	}
+
+	previous, ok := readAppVolumeManifest(ctx, client, volumeName, manifestPath, uid, gid)
+	if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if !ok {
+		logger.Verbose("Creating app volume %s", style.Symbol(volumeName))
+		if err := client.VolumeRemove(ctx, volumeName, true); err != nil && !dockerclient.IsErrNotFound(err) {
+			return errors.Wrapf(err, "failed to reset app volume %s", style.Symbol(volumeName))
+		}
+	}
+
+	changed, removed := current.Diff(previous)
+	logger.Verbose("Syncing app volume %s (%d changed, %d removed)", style.Symbol(volumeName), len(changed), len(removed))
+
+	cmd := []string{"rm", "-rf", "--"}
+	for _, p := range removed {
+		cmd = append(cmd, path.Join(appDir, p))
+	}
+	ctr, err := client.ContainerCreate(ctx, &container.Config{
+		Image:  builderImage,
+		User:   "root",
+		Cmd:    cmd,
+		Labels: map[string]string{"author": "pack"},
+	}, &container.HostConfig{
+		Binds: []string{fmt.Sprintf("%s:%s:", volumeName, appDir)},
+	}, nil, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create container to sync app volume %s", style.Symbol(volumeName))
+	}
+	defer client.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true})
+
+	if len(removed) > 0 {
+		if err := client.RunContainer(ctx, ctr.ID, logger.VerboseWriter().WithPrefix("sync"), logger.VerboseErrorWriter().WithPrefix("sync")); err != nil {
+			return errors.Wrapf(err, "failed to remove deleted files from app volume %s", style.Symbol(volumeName))
+		}
+	}
+
+	if len(changed) > 0 {
+		isChanged := map[string]bool{}
+		for _, p := range changed {
+			isChanged[p] = true
+		}
+		appReader, errChan := archive.CreateFilteredTarReader(srcDir, appDir, uid, gid, func(p string) bool { return isChanged[p] })
+		if err := client.CopyToContainer(ctx, ctr.ID, "/", appReader, types.CopyToContainerOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to copy app to volume %s", style.Symbol(volumeName))
+		}
+		if err := <-errChan; err != nil {
+			return errors.Wrapf(err, "failed to read app directory %s", style.Symbol(srcDir))
+		}
+	}
+
+	volume, err := client.VolumeInspect(ctx, volumeName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to inspect app volume %s", style.Symbol(volumeName))
+	}
+	return writeAppVolumeManifest(manifestPath, appVolumeManifest{
+		VolumeCreatedAt: volume.CreatedAt,
+		UID:             uid,
+		GID:             gid,
+		Entries:         current,
+	})
+}
+
+// readAppVolumeManifest returns the entries last synced into the volume, if they are known to still be accurate
+func readAppVolumeManifest(ctx context.Context, client *docker.Client, volumeName, manifestPath string, uid, gid int) (archive.Manifest, bool) {
+	volume, err := client.VolumeInspect(ctx, volumeName)
+	if err != nil {
+		return nil, false
+	}
+
+	contents, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, false
+	}
+	var manifest appVolumeManifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, false
+	}
+
+	if manifest.VolumeCreatedAt != volume.CreatedAt || manifest.UID != uid || manifest.GID != gid || manifest.Entries == nil {
+		return nil, false
+	}
+	return manifest.Entries, true
+}
+
+func writeAppVolumeManifest(manifestPath string, manifest appVolumeManifest) error {
+	contents, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestPath, contents, 0644)
+}
@@ -0,0 +1,98 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	creatorName = "creator"
+	// daemonDir holds the Docker daemon socket in the creator container, and is made accessible to root only
+	daemonDir = "/pack-daemon"
+)
+
+// creatorStep is a lifecycle phase run by the creator
+type creatorStep struct {
+	name       string
+	args       []string
+	privileged bool
+}
+
+// NewCreate returns a phase that runs the lifecycle in a single container. Each lifecycle phase is executed as its own
+// process in the container, so that its output and failure are attributed to it. The restorer, analyzer, exporter and
+// cacher run as root with access to the Docker daemon and, when publishing, the registry credentials. Detection and
+// building run as the builder's user, which can reach neither.
+func (l *Lifecycle) NewCreate(repoName, runImage, cacheImage string, publish, clearCache bool) (*Phase, error) {
+	steps := []creatorStep{{name: "detector", args: l.args.detect()}}
+	if !clearCache {
+		steps = append(steps,
+			creatorStep{name: "restorer", args: l.args.restore(cacheImage), privileged: true},
+			creatorStep{name: "analyzer", args: l.args.analyze(repoName, publish), privileged: true},
+		)
+	}
+	steps = append(steps,
+		creatorStep{name: "builder", args: l.args.build()},
+		creatorStep{name: "exporter", args: l.args.export(repoName, runImage, publish), privileged: true},
+		creatorStep{name: "cacher", args: l.args.cache(cacheImage), privileged: true},
+	)
+	return l.NewPhase(creatorName, withCreatorSteps(steps, publish, repoName, runImage))
+}
+
+func withCreatorSteps(steps []creatorStep, publish bool, repos ...string) func(*Phase) (*Phase, error) {
+	return func(phase *Phase) (*Phase, error) {
+		phase.ctrConf.User = "root"
+		// keeps the container running while the steps are executed in it
+		phase.ctrConf.Cmd = []string{"sleep", "2147483647"}
+		phase.hostConf.Binds = append(phase.hostConf.Binds, fmt.Sprintf("/var/run/docker.sock:%s/docker.sock", daemonDir))
+		phase.privilegedEnv = []string{fmt.Sprintf("DOCKER_HOST=unix://%s/docker.sock", daemonDir)}
+		if publish {
+			authEnv, err := registryAuthEnv(repos...)
+			if err != nil {
+				return nil, err
+			}
+			phase.privilegedEnv = append(phase.privilegedEnv, authEnv)
+			phase.hostConf.NetworkMode = "host"
+		}
+		phase.steps = steps
+		return phase, nil
+	}
+}
+
+func (p *Phase) runSteps(ctx context.Context, stdout, stderr io.Writer) error {
+	if err := p.docker.ContainerStart(ctx, p.ctr.ID, types.ContainerStartOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to start '%s' container", p.name)
+	}
+	// the daemon socket must be out of reach of the builder's user before any buildpack runs
+	restrict := types.ExecConfig{User: "root", Cmd: []string{"chmod", "0700", daemonDir}}
+	if err := p.docker.ExecContainer(ctx, p.ctr.ID, restrict, stdout, stderr); err != nil {
+		return errors.Wrapf(err, "failed to restrict access to the Docker daemon in '%s' container", p.name)
+	}
+
+	for _, step := range p.steps {
+		config := types.ExecConfig{
+			User: fmt.Sprintf("%d:%d", p.uid, p.gid),
+			Cmd:  append([]string{lifecycleDir + "/" + step.name}, step.args...),
+		}
+		if step.privileged {
+			config.User = "root"
+			config.Env = p.privilegedEnv
+		}
+		err := p.docker.ExecContainer(
+			ctx,
+			p.ctr.ID,
+			config,
+			p.logger.VerboseWriter().WithPrefix(step.name),
+			p.logger.VerboseErrorWriter().WithPrefix(step.name),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "%s phase failed", style.Symbol(step.name))
+		}
+	}
+	return nil
+}
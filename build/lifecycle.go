@@ -3,6 +3,7 @@ package build
 import (
 	"archive/tar"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/builder"
 	"github.com/buildpack/pack/docker"
 	"github.com/buildpack/pack/logging"
 	"github.com/buildpack/pack/style"
@@ -37,14 +39,23 @@ type Lifecycle struct {
 	Docker       Docker
 	LayersVolume string
 	AppVolume    string
-	uid, gid     int
-	appDir       string
-	appOnce      *sync.Once
+	// LifecycleVolume holds lifecycle binaries supplied by a separate image, when one is used
+	LifecycleVolume string
+	uid, gid        int
+	appDir          string
+	appOnce         *sync.Once
+	args            phaseArgs
+	// inputs are tars of the platform env, order and ad-hoc buildpacks, copied into every phase container
+	inputs []string
+	tmpDir string
 }
 
 type Docker interface {
 	RunContainer(ctx context.Context, id string, stdout io.Writer, stderr io.Writer) error
+	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
+	ExecContainer(ctx context.Context, id string, config types.ExecConfig, stdout io.Writer, stderr io.Writer) error
 	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options types.CopyToContainerOptions) error
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error)
 	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.ContainerCreateCreatedBody, error)
 	ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error
 	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
@@ -59,8 +70,22 @@ type LifecycleConfig struct {
 	Env          map[string]string
 	Buildpacks   []string
 	AppDir       string
+	// Order, when set, replaces the builder's order and cannot be combined with Buildpacks
+	Order *Order
+	// LifecycleImage, when set, supplies the lifecycle binaries instead of the builder
+	LifecycleImage string
+	// AppVolume, when set, names a persistent app volume that is synced incrementally and kept between builds,
+	// using the manifest at AppVolumeManifest to track what it contains. Builds run on a copy of it.
+	AppVolume         string
+	AppVolumeManifest string
 }
 
+const (
+	LifecycleVersionLabel     = "io.buildpacks.lifecycle.version"
+	LifecyclePlatformAPILabel = "io.buildpacks.lifecycle.platform-api"
+	lifecycleDir              = "/lifecycle"
+)
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
@@ -79,61 +104,105 @@ func NewLifecycle(c LifecycleConfig) (*Lifecycle, error) {
 	if err != nil {
 		return nil, err
 	}
-	builder.Rename(fmt.Sprintf("pack.local/builder/%x", randString(10)))
-	uid, gid, err := packUidGid(builder)
+	lifecycleMetadata, err := builderLifecycle(builder)
 	if err != nil {
 		return nil, err
 	}
 
-	tmpDir, err := ioutil.TempDir("", "pack.build.tars")
-	defer os.RemoveAll(tmpDir)
+	if c.LifecycleImage != "" {
+		lifecycleImage, err := factory.NewLocal(c.LifecycleImage)
+		if err != nil {
+			return nil, err
+		}
+		if lifecycleMetadata, err = imageLifecycle(lifecycleImage, lifecycleMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	args, err := lifecyclePhaseArgs(builder.Name(), lifecycleMetadata, c.Logger)
 	if err != nil {
 		return nil, err
 	}
 
-	envTar, err := tarEnvFile(tmpDir, c.Env)
-	defer os.RemoveAll(envTar)
+	uid, gid, err := packUidGid(builder)
 	if err != nil {
 		return nil, err
 	}
-	if err := builder.AddLayer(envTar); err != nil {
+
+	tmpDir, err := ioutil.TempDir("", "pack.build.tars")
+	if err != nil {
 		return nil, err
 	}
 
-	if len(c.Buildpacks) != 0 {
-		tars, err := createBuildpacksTars(tmpDir, c.Buildpacks, c.Logger, uid, gid)
-		if err != nil {
+	inputs, err := createInputTars(tmpDir, c, uid, gid)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	appVolume := "pack-app-" + randString(10)
+	appOnce := &sync.Once{}
+	if c.AppVolume != "" {
+		if err := populateFromAppVolume(client, c, appVolume, uid, gid); err != nil {
+			os.RemoveAll(tmpDir)
 			return nil, err
 		}
+		// the app is already in the volume, so phases must not copy it again
+		appOnce.Do(func() {})
+	}
 
-		for _, t := range tars {
-			if err := builder.AddLayer(t); err != nil {
-				return nil, err
-			}
+	var lifecycleVolume string
+	if c.LifecycleImage != "" {
+		c.Logger.Verbose("Using lifecycle from image %s", style.Symbol(c.LifecycleImage))
+		lifecycleVolume = "pack-lifecycle-" + randString(10)
+		if err := populateLifecycleVolume(client, c.LifecycleImage, lifecycleVolume); err != nil {
+			os.RemoveAll(tmpDir)
+			return nil, err
 		}
 	}
 
-	if _, err := builder.Save(); err != nil {
+	return &Lifecycle{
+		BuilderImage:    builder.Name(),
+		Logger:          c.Logger,
+		Docker:          client,
+		LayersVolume:    "pack-layers-" + randString(10),
+		AppVolume:       appVolume,
+		LifecycleVolume: lifecycleVolume,
+		appDir:          c.AppDir,
+		uid:             uid,
+		gid:             gid,
+		appOnce:         appOnce,
+		args:            args,
+		inputs:          inputs,
+		tmpDir:          tmpDir,
+	}, nil
+}
+
+func createInputTars(tmpDir string, c LifecycleConfig, uid, gid int) ([]string, error) {
+	envTar, err := tarEnvFile(tmpDir, c.Env)
+	if err != nil {
 		return nil, err
 	}
+	inputs := []string{envTar}
 
-	return &Lifecycle{
-		BuilderImage: builder.Name(),
-		Logger:       c.Logger,
-		Docker:       client,
-		LayersVolume: "pack-layers-" + randString(10),
-		AppVolume:    "pack-app-" + randString(10),
-		appDir:       c.AppDir,
-		uid:          uid,
-		gid:          gid,
-		appOnce:      &sync.Once{},
-	}, nil
+	order := c.Order
+	if len(c.Buildpacks) != 0 {
+		order = orderFromBuildpacks(c.Buildpacks)
+	}
+	if order != nil {
+		tars, err := createOrderTars(tmpDir, *order, c.Logger, uid, gid)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, tars...)
+	}
+	return inputs, nil
 }
 
 func (l *Lifecycle) Cleanup() error {
 	var reterr error
-	if _, err := l.Docker.ImageRemove(context.Background(), l.BuilderImage, types.ImageRemoveOptions{}); err != nil {
-		reterr = errors.Wrapf(err, "failed to clean up builder image %s", l.BuilderImage)
+	if err := os.RemoveAll(l.tmpDir); err != nil {
+		reterr = errors.Wrapf(err, "failed to clean up build inputs %s", l.tmpDir)
 	}
 	if err := l.Docker.VolumeRemove(context.Background(), l.LayersVolume, true); err != nil {
 		reterr = errors.Wrapf(err, "failed to clean up layers volume %s", l.LayersVolume)
@@ -141,6 +210,11 @@ func (l *Lifecycle) Cleanup() error {
 	if err := l.Docker.VolumeRemove(context.Background(), l.AppVolume, true); err != nil {
 		reterr = errors.Wrapf(err, "failed to clean up app volume %s", l.AppVolume)
 	}
+	if l.LifecycleVolume != "" {
+		if err := l.Docker.VolumeRemove(context.Background(), l.LifecycleVolume, true); err != nil {
+			reterr = errors.Wrapf(err, "failed to clean up lifecycle volume %s", l.LifecycleVolume)
+		}
+	}
 	return reterr
 }
 
@@ -152,6 +226,118 @@ func randString(n int) string {
 	return string(b)
 }
 
+func builderLifecycle(img image.Image) (builder.LifecycleMetadata, error) {
+	label, err := img.Label(builder.MetadataLabel)
+	if err != nil {
+		return builder.LifecycleMetadata{}, errors.Wrapf(err, "reading label %s from builder %s", style.Symbol(builder.MetadataLabel), style.Symbol(img.Name()))
+	}
+
+	var metadata builder.Metadata
+	if label != "" {
+		if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+			return builder.LifecycleMetadata{}, errors.Wrapf(err, "parsing metadata for builder %s", style.Symbol(img.Name()))
+		}
+	}
+	return metadata.Lifecycle, nil
+}
+
+// imageLifecycle reads the lifecycle version and platform API from the labels of a lifecycle image,
+// falling back to what the builder declares for any label that is missing
+func imageLifecycle(img image.Image, fallback builder.LifecycleMetadata) (builder.LifecycleMetadata, error) {
+	if found, err := img.Found(); err != nil {
+		return builder.LifecycleMetadata{}, errors.Wrapf(err, "finding lifecycle image %s", style.Symbol(img.Name()))
+	} else if !found {
+		return builder.LifecycleMetadata{}, fmt.Errorf("lifecycle image %s does not exist", style.Symbol(img.Name()))
+	}
+
+	metadata := builder.LifecycleMetadata{}
+	var err error
+	if metadata.Version, err = img.Label(LifecycleVersionLabel); err != nil {
+		return builder.LifecycleMetadata{}, err
+	}
+	if metadata.PlatformAPI, err = img.Label(LifecyclePlatformAPILabel); err != nil {
+		return builder.LifecycleMetadata{}, err
+	}
+
+	if metadata.Version == "" {
+		metadata.Version = fallback.Version
+	}
+	if metadata.PlatformAPI == "" {
+		metadata.PlatformAPI = fallback.PlatformAPI
+	}
+	return metadata, nil
+}
+
+// populateLifecycleVolume copies /lifecycle from the lifecycle image into a new volume. Neither container is started:
+// one is only read from, and the other mounts the volume without copying image content into it so that the copy is
+// explicit. The volume is removed when it cannot be filled.
+func populateLifecycleVolume(client Docker, lifecycleImage, volumeName string) (err error) {
+	ctx := context.Background()
+	defer func() {
+		if err != nil {
+			client.VolumeRemove(ctx, volumeName, true)
+			err = errors.Wrapf(err, "failed to populate lifecycle volume %s from image %s", style.Symbol(volumeName), style.Symbol(lifecycleImage))
+		}
+	}()
+
+	src, err := createLifecycleContainer(ctx, client, lifecycleImage, nil)
+	if err != nil {
+		return err
+	}
+	defer client.ContainerRemove(ctx, src, types.ContainerRemoveOptions{Force: true})
+
+	dst, err := createLifecycleContainer(ctx, client, lifecycleImage, []string{fmt.Sprintf("%s:%s:nocopy", volumeName, lifecycleDir)})
+	if err != nil {
+		return err
+	}
+	defer client.ContainerRemove(ctx, dst, types.ContainerRemoveOptions{Force: true})
+
+	rc, _, err := client.CopyFromContainer(ctx, src, lifecycleDir)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return client.CopyToContainer(ctx, dst, "/", rc, types.CopyToContainerOptions{})
+}
+
+func createLifecycleContainer(ctx context.Context, client Docker, lifecycleImage string, binds []string) (string, error) {
+	ctr, err := client.ContainerCreate(ctx, &container.Config{
+		Image:  lifecycleImage,
+		Cmd:    []string{lifecycleDir + "/detector"},
+		Labels: map[string]string{"author": "pack"},
+	}, &container.HostConfig{
+		Binds: binds,
+	}, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return ctr.ID, nil
+}
+
+func lifecyclePhaseArgs(builderName string, metadata builder.LifecycleMetadata, logger *logging.Logger) (phaseArgs, error) {
+	platformAPI := metadata.PlatformAPI
+	if platformAPI == "" {
+		logger.Verbose("Builder %s does not declare a lifecycle platform API, assuming %s", style.Symbol(builderName), style.Symbol(DefaultPlatformAPI))
+		platformAPI = DefaultPlatformAPI
+	}
+
+	args, ok := platformAPIs[platformAPI]
+	if !ok {
+		version := metadata.Version
+		if version == "" {
+			version = "unknown"
+		}
+		return nil, fmt.Errorf(
+			"builder %s has lifecycle %s with platform API %s, which is not supported by this version of pack (supported platform APIs: %s) -- try upgrading pack",
+			style.Symbol(builderName),
+			style.Symbol(version),
+			style.Symbol(platformAPI),
+			strings.Join(SupportedPlatformAPIs(), ", "),
+		)
+	}
+	return args, nil
+}
+
 func packUidGid(builder image.Image) (int, int, error) {
 	sUID, err := builder.Env("CNB_USER_ID")
 	if err != nil {
@@ -199,58 +385,90 @@ func tarEnvFile(tmpDir string, env map[string]string) (string, error) {
 	return fh.Name(), nil
 }
 
-func createBuildpacksTars(tmpDir string, buildpacks []string, logger *logging.Logger, uid int, gid int) ([]string, error) {
-	tars := make([]string, 0, len(buildpacks)+1)
-
-	var buildpackGroup []*lifecycle.Buildpack
+// orderFromBuildpacks turns buildpack IDs and directories given on the command line into a single required group
+func orderFromBuildpacks(buildpacks []string) *Order {
+	var group OrderGroup
 	for _, bp := range buildpacks {
-		var id, version string
 		if _, err := os.Stat(filepath.Join(bp, "buildpack.toml")); !os.IsNotExist(err) {
-			if runtime.GOOS == "windows" {
-				return nil, fmt.Errorf("directory buildpacks are not implemented on windows")
-			}
-			var buildpackTOML struct {
-				Buildpack lifecycle.Buildpack
-			}
+			group.Buildpacks = append(group.Buildpacks, OrderBuildpack{Path: bp})
+		} else {
+			group.Buildpacks = append(group.Buildpacks, OrderBuildpack{ID: bp})
+		}
+	}
+	return &Order{Groups: []OrderGroup{group}}
+}
 
-			_, err = toml.DecodeFile(filepath.Join(bp, "buildpack.toml"), &buildpackTOML)
-			if err != nil {
-				return nil, fmt.Errorf(`failed to decode buildpack.toml from "%s": %s`, bp, err)
-			}
-			id = buildpackTOML.Buildpack.ID
-			version = buildpackTOML.Buildpack.Version
+func createOrderTars(tmpDir string, order Order, logger *logging.Logger, uid int, gid int) ([]string, error) {
+	var tars []string
+	// local holds the directory each local buildpack was read from, by id@version
+	local := map[string]string{}
 
-			tarFile := filepath.Join(tmpDir, fmt.Sprintf("%s.%s.tar", buildpackTOML.Buildpack.EscapedID(), version))
+	var groups lifecycle.BuildpackOrder
+	for _, orderGroup := range order.Groups {
+		var group lifecycle.BuildpackGroup
+		for _, bp := range orderGroup.Buildpacks {
+			var id, version string
+			if bp.Path != "" {
+				localBP, err := readLocalBuildpack(bp.Path)
+				if err != nil {
+					return nil, err
+				}
+				path, err := filepath.Abs(bp.Path)
+				if err != nil {
+					return nil, err
+				}
 
-			if err := archive.CreateTar(tarFile, bp, filepath.Join(buildpacksDir, buildpackTOML.Buildpack.EscapedID(), version), uid, gid); err != nil {
-				return nil, err
+				key := localBP.ID + "@" + localBP.Version
+				if prevPath, ok := local[key]; !ok {
+					tarFile := filepath.Join(tmpDir, fmt.Sprintf("%s.%s.tar", localBP.EscapedID(), localBP.Version))
+					if err := archive.CreateTar(tarFile, bp.Path, filepath.Join(buildpacksDir, localBP.EscapedID(), localBP.Version), uid, gid); err != nil {
+						return nil, err
+					}
+					tars = append(tars, tarFile)
+					local[key] = path
+				} else if prevPath != path {
+					return nil, fmt.Errorf(
+						"buildpack %s is read from both %s and %s",
+						style.Symbol(key),
+						style.Symbol(prevPath),
+						style.Symbol(path),
+					)
+				}
+				id, version = localBP.ID, localBP.Version
+			} else if bp.Version != "" {
+				id, version = bp.ID, bp.Version
+			} else {
+				id, version = parseBuildpack(bp.ID, logger)
 			}
-
-			tars = append(tars, tarFile)
-		} else {
-			id, version = parseBuildpack(bp, logger)
+			group.Buildpacks = append(
+				group.Buildpacks,
+				&lifecycle.Buildpack{ID: id, Version: version, Optional: bp.Optional},
+			)
 		}
-		buildpackGroup = append(
-			buildpackGroup,
-			&lifecycle.Buildpack{ID: id, Version: version, Optional: false},
-		)
+		groups = append(groups, group)
 	}
 
-	orderTarPath, err := orderTar(tmpDir, buildpackGroup)
+	orderTarPath, err := orderTar(tmpDir, groups)
 	if err != nil {
 		return nil, err
 	}
-	tars = append(tars, orderTarPath)
-	return tars, nil
+	return append(tars, orderTarPath), nil
 }
 
-func orderTar(tmpDir string, buildpacks []*lifecycle.Buildpack) (string, error) {
-	groups := lifecycle.BuildpackOrder{
-		lifecycle.BuildpackGroup{
-			Buildpacks: buildpacks,
-		},
+func readLocalBuildpack(dir string) (lifecycle.Buildpack, error) {
+	if runtime.GOOS == "windows" {
+		return lifecycle.Buildpack{}, fmt.Errorf("directory buildpacks are not implemented on windows")
 	}
+	var buildpackTOML struct {
+		Buildpack lifecycle.Buildpack
+	}
+	if _, err := toml.DecodeFile(filepath.Join(dir, "buildpack.toml"), &buildpackTOML); err != nil {
+		return lifecycle.Buildpack{}, fmt.Errorf(`failed to decode buildpack.toml from "%s": %s`, dir, err)
+	}
+	return buildpackTOML.Buildpack, nil
+}
 
+func orderTar(tmpDir string, groups lifecycle.BuildpackOrder) (string, error) {
 	var tomlBuilder strings.Builder
 	if err := toml.NewEncoder(&tomlBuilder).Encode(map[string]interface{}{"groups": groups}); err != nil {
 		return "", errors.Wrapf(err, "encoding order.toml: %#v", groups)
@@ -1,13 +1,16 @@
 package build_test
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
@@ -15,6 +18,7 @@ import (
 
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	dockerclient "github.com/docker/docker/client"
 	"github.com/fatih/color"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -161,6 +165,33 @@ func testLifecycle(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 
+				when("#WithOutput", func() {
+					it("writes the output of the phase to the given writers", func() {
+						var phaseOut bytes.Buffer
+						phase, err := lifecycle.NewPhase("phase", build.WithOutput(&phaseOut, &phaseOut))
+						h.AssertNil(t, err)
+						assertRunSucceeds(t, phase, &outBuf, &errBuf)
+						h.AssertContains(t, phaseOut.String(), "running some-lifecycle-phase")
+						h.AssertNotContains(t, outBuf.String(), "running some-lifecycle-phase")
+					})
+				})
+
+				when("#Layers", func() {
+					it("copies the layers directory out of the phase container", func() {
+						phase, err := lifecycle.NewPhase("phase")
+						h.AssertNil(t, err)
+						defer phase.Cleanup()
+						h.AssertNil(t, phase.Run(context.TODO()))
+
+						layers, err := phase.Layers(context.TODO())
+						h.AssertNil(t, err)
+						defer layers.Close()
+						header, err := tar.NewReader(layers).Next()
+						h.AssertNil(t, err)
+						h.AssertEq(t, header.Name, "layers/")
+					})
+				})
+
 				when("#WithDaemonAccess", func() {
 					it("allows daemon access inside the container", func() {
 						phase, err := lifecycle.NewPhase(
@@ -197,6 +228,44 @@ func testLifecycle(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 			})
+
+			when("#NewCreate", func() {
+				it("runs every phase in one container with per-phase output", func() {
+					create, err := lifecycle.NewCreate("some/app", "some/run", "some/cache", false, false)
+					h.AssertNil(t, err)
+					assertRunSucceeds(t, create, &outBuf, &errBuf)
+					for _, name := range []string{"detector", "restorer", "analyzer", "builder", "exporter", "cacher"} {
+						h.AssertContains(t, outBuf.String(), "["+name+"] received args [/lifecycle/"+name)
+					}
+				})
+
+				it("drops privileges for detection and building only", func() {
+					create, err := lifecycle.NewCreate("some/app", "some/run", "some/cache", false, false)
+					h.AssertNil(t, err)
+					assertRunSucceeds(t, create, &outBuf, &errBuf)
+					h.AssertContains(t, outBuf.String(), "[detector] running as uid 111")
+					h.AssertContains(t, outBuf.String(), "[builder] running as uid 111")
+					h.AssertContains(t, outBuf.String(), "[exporter] running as uid 0")
+					h.AssertContains(t, outBuf.String(), "[cacher] running as uid 0")
+				})
+
+				it("keeps the daemon socket out of reach of detection and building", func() {
+					create, err := lifecycle.NewCreate("some/app", "some/run", "some/cache", false, false)
+					h.AssertNil(t, err)
+					assertRunSucceeds(t, create, &outBuf, &errBuf)
+					h.AssertContains(t, outBuf.String(), "[exporter] daemon socket reachable")
+					h.AssertNotContains(t, outBuf.String(), "[detector] daemon socket reachable")
+					h.AssertNotContains(t, outBuf.String(), "[builder] daemon socket reachable")
+				})
+
+				it("skips restoring and analyzing when clearing the cache", func() {
+					create, err := lifecycle.NewCreate("some/app", "some/run", "some/cache", false, true)
+					h.AssertNil(t, err)
+					assertRunSucceeds(t, create, &outBuf, &errBuf)
+					h.AssertNotContains(t, outBuf.String(), "[restorer]")
+					h.AssertNotContains(t, outBuf.String(), "[analyzer]")
+				})
+			})
 		})
 
 		when("there are user provided custom buildpacks", func() {
@@ -253,6 +322,90 @@ func testLifecycle(t *testing.T, when spec.G, it spec.S) {
 `)
 			})
 		})
+		when("there is a user provided order", func() {
+			it.Before(func() {
+				if runtime.GOOS == "windows" {
+					t.Skip("directory buildpacks are not implemented on windows")
+				}
+				var err error
+				lifecycle, err = build.NewLifecycle(
+					build.LifecycleConfig{
+						BuilderImage: repoName,
+						Logger:       logger,
+						Order: &build.Order{Groups: []build.OrderGroup{
+							{Buildpacks: []build.OrderBuildpack{
+								{Path: filepath.Join("testdata", "fake_buildpack")},
+								{ID: "optional.bp", Version: "1.0.0", Optional: true},
+							}},
+							{Buildpacks: []build.OrderBuildpack{
+								{ID: "fallback.bp"},
+							}},
+						}},
+						Env: map[string]string{},
+					},
+				)
+				h.AssertNil(t, err)
+			})
+
+			it("runs the phase with each group in order.toml", func() {
+				phase, err := lifecycle.NewPhase("phase", build.WithArgs("read", "/buildpacks/order.toml"))
+				h.AssertNil(t, err)
+				assertRunSucceeds(t, phase, &outBuf, &errBuf)
+				h.AssertContains(t, strings.Replace(outBuf.String(), "[phase]", "", -1),
+					`
+   [[groups.buildpacks]]
+     id = "optional.bp"
+     version = "1.0.0"
+     optional = true
+`)
+				h.AssertContains(t, strings.Replace(outBuf.String(), "[phase]", "", -1),
+					`
+   [[groups.buildpacks]]
+     id = "fallback.bp"
+     version = "latest"
+`)
+			})
+
+			it("runs the phase with local buildpacks available", func() {
+				phase, err := lifecycle.NewPhase("phase", build.WithArgs("buildpacks"))
+				h.AssertNil(t, err)
+				assertRunSucceeds(t, phase, &outBuf, &errBuf)
+				h.AssertContains(t, outBuf.String(), "[phase] /buildpacks/test.bp/0.0.1-test 111/222")
+			})
+		})
+
+		when("the user provided order reads the same buildpack from two directories", func() {
+			var tmpDir string
+
+			it.Before(func() {
+				if runtime.GOOS == "windows" {
+					t.Skip("directory buildpacks are not implemented on windows")
+				}
+				var err error
+				tmpDir, err = ioutil.TempDir("", "lifecycle-order")
+				h.AssertNil(t, err)
+				h.RecursiveCopy(t, filepath.Join("testdata", "fake_buildpack"), tmpDir)
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.RemoveAll(tmpDir))
+			})
+
+			it("returns an error", func() {
+				_, err := build.NewLifecycle(
+					build.LifecycleConfig{
+						BuilderImage: repoName,
+						Logger:       logger,
+						Order: &build.Order{Groups: []build.OrderGroup{
+							{Buildpacks: []build.OrderBuildpack{{Path: filepath.Join("testdata", "fake_buildpack")}}},
+							{Buildpacks: []build.OrderBuildpack{{Path: tmpDir}}},
+						}},
+					},
+				)
+				h.AssertError(t, err, "buildpack 'test.bp@0.0.1-test' is read from both")
+			})
+		})
+
 		when("there are user provided buildpack names", func() {
 			it.Before(func() {
 				var err error
@@ -295,6 +448,186 @@ func testLifecycle(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("a persistent app volume is used", func() {
+		var (
+			outBuf, errBuf bytes.Buffer
+			appDir, tmpDir string
+			volumeName     string
+			config         build.LifecycleConfig
+		)
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "lifecycle-app-volume")
+			h.AssertNil(t, err)
+			appDir = filepath.Join(tmpDir, "app")
+			h.RecursiveCopy(t, filepath.Join("testdata", "fake-app"), appDir)
+			volumeName = "pack-app-test-" + h.RandString(10)
+			config = build.LifecycleConfig{
+				BuilderImage:      repoName,
+				AppDir:            appDir,
+				Logger:            logging.NewLogger(&outBuf, &errBuf, true, false),
+				Env:               map[string]string{},
+				AppVolume:         volumeName,
+				AppVolumeManifest: filepath.Join(tmpDir, "manifest.json"),
+			}
+		})
+
+		it.After(func() {
+			h.AssertNil(t, dockerCli.VolumeRemove(context.TODO(), volumeName, true))
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		runPhase := func(args ...string) {
+			t.Helper()
+			lifecycle, err := build.NewLifecycle(config)
+			h.AssertNil(t, err)
+			defer lifecycle.Cleanup()
+			h.AssertNotEq(t, lifecycle.AppVolume, volumeName)
+			phase, err := lifecycle.NewPhase("phase", build.WithArgs(args...))
+			h.AssertNil(t, err)
+			assertRunSucceeds(t, phase, &outBuf, &errBuf)
+		}
+
+		it("keeps the volume and syncs changed and deleted files on the next build", func() {
+			runPhase("read", "/workspace/fake-app-file")
+			h.AssertContains(t, outBuf.String(), "[phase] file contents: fake-app-contents")
+
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "fake-app-file"), []byte("changed-contents"), 0644))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "new-file"), []byte("new-contents"), 0644))
+			runPhase("read", "/workspace/fake-app-file")
+			h.AssertContains(t, outBuf.String(), "(2 changed, 0 removed)")
+			h.AssertContains(t, outBuf.String(), "[phase] file contents: changed-contents")
+
+			h.AssertNil(t, os.Remove(filepath.Join(appDir, "new-file")))
+			runPhase("read", "/workspace/fake-app-file")
+			h.AssertContains(t, outBuf.String(), "(0 changed, 1 removed)")
+		})
+
+		it("does not keep changes that a build makes to the app", func() {
+			runPhase("write", "/workspace/build-output", "some-output")
+			runPhase("delete", "/workspace/fake-app-file")
+
+			outBuf.Reset()
+			runPhase("read", "/workspace/fake-app-file")
+			h.AssertContains(t, outBuf.String(), "(0 changed, 0 removed)")
+			h.AssertContains(t, outBuf.String(), "[phase] file contents: fake-app-contents")
+
+			lifecycle, err := build.NewLifecycle(config)
+			h.AssertNil(t, err)
+			defer lifecycle.Cleanup()
+			phase, err := lifecycle.NewPhase("phase", build.WithArgs("read", "/workspace/build-output"))
+			h.AssertNil(t, err)
+			h.AssertError(t, phase.Run(context.TODO()), "failed with status code: 7")
+		})
+	})
+
+	when("a lifecycle image is used", func() {
+		var (
+			outBuf bytes.Buffer
+			config build.LifecycleConfig
+		)
+
+		it.Before(func() {
+			config = build.LifecycleConfig{
+				BuilderImage:   repoName,
+				AppDir:         filepath.Join("testdata", "fake-app"),
+				Logger:         logging.NewLogger(&outBuf, &outBuf, true, false),
+				LifecycleImage: repoName,
+			}
+		})
+
+		it("runs the phases from a volume holding the lifecycle of the image", func() {
+			lifecycle, err := build.NewLifecycle(config)
+			h.AssertNil(t, err)
+			defer lifecycle.Cleanup()
+
+			phase, err := lifecycle.NewPhase("phase")
+			h.AssertNil(t, err)
+			assertRunSucceeds(t, phase, &outBuf, &outBuf)
+			h.AssertContains(t, outBuf.String(), "running some-lifecycle-phase")
+		})
+
+		when("the lifecycle image has no lifecycle", func() {
+			it.Before(func() {
+				config.LifecycleImage = "lifecycle.test.empty." + h.RandString(10)
+				h.CreateImageOnLocal(t, dockerCli, config.LifecycleImage, "FROM scratch\nLABEL io.buildpacks.lifecycle.platform-api=0.1\n")
+			})
+
+			it.After(func() {
+				h.AssertNil(t, h.DockerRmi(dockerCli, config.LifecycleImage))
+			})
+
+			it("fails and removes the lifecycle volume", func() {
+				_, err := build.NewLifecycle(config)
+				h.AssertError(t, err, fmt.Sprintf("from image '%s'", config.LifecycleImage))
+
+				volumeName := regexp.MustCompile(`pack-lifecycle-[a-z]+`).FindString(err.Error())
+				h.AssertNotEq(t, volumeName, "")
+				_, err = dockerCli.VolumeInspect(context.TODO(), volumeName)
+				h.AssertEq(t, dockerclient.IsErrNotFound(err), true)
+			})
+		})
+	})
+
+	when("a phase run in a single container fails", func() {
+		var builderName string
+
+		it.Before(func() {
+			builderName = "lifecycle.test.failing." + h.RandString(10)
+			h.CreateImageOnLocal(t, dockerCli, builderName, fmt.Sprintf("FROM %s\nRUN ln -sf /bin/false /lifecycle/builder\n", repoName))
+		})
+
+		it.After(func() {
+			h.AssertNil(t, h.DockerRmi(dockerCli, builderName))
+		})
+
+		it("reports the phase that failed", func() {
+			var outBuf bytes.Buffer
+			lifecycle, err := build.NewLifecycle(build.LifecycleConfig{
+				BuilderImage: builderName,
+				AppDir:       filepath.Join("testdata", "fake-app"),
+				Logger:       logging.NewLogger(&outBuf, &outBuf, false, false),
+			})
+			h.AssertNil(t, err)
+			defer lifecycle.Cleanup()
+
+			create, err := lifecycle.NewCreate("some/app", "some/run", "some/cache", false, false)
+			h.AssertNil(t, err)
+			defer create.Cleanup()
+			h.AssertError(t, create.Run(context.TODO()), "'builder' phase failed: failed with status code: 1")
+		})
+	})
+
+	when("the builder declares an unsupported platform API", func() {
+		var builderName string
+
+		it.Before(func() {
+			builderName = "lifecycle.test.unsupported." + h.RandString(10)
+			h.CreateImageOnLocal(t, dockerCli, builderName, fmt.Sprintf(`
+FROM %s
+LABEL io.buildpacks.builder.metadata="{\"lifecycle\": {\"version\": \"9.9.9\", \"platformApi\": \"9.9\"}}"
+`, repoName))
+		})
+
+		it.After(func() {
+			h.AssertNil(t, h.DockerRmi(dockerCli, builderName))
+		})
+
+		it("fails before running any phase", func() {
+			var outBuf bytes.Buffer
+			_, err := build.NewLifecycle(build.LifecycleConfig{
+				BuilderImage: builderName,
+				AppDir:       filepath.Join("testdata", "fake-app"),
+				Logger:       logging.NewLogger(&outBuf, &outBuf, false, false),
+			})
+			h.AssertError(t, err, fmt.Sprintf(
+				"builder '%s' has lifecycle '9.9.9' with platform API '9.9', which is not supported by this version of pack (supported platform APIs: 0.1) -- try upgrading pack",
+				builderName,
+			))
+		})
+	})
+
 	when("#Cleanup", func() {
 		var (
 			subject        *build.Lifecycle
@@ -340,23 +673,21 @@ func testLifecycle(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, len(body.Volumes), 0)
 		})
 
-		it("should remove the builder image", func() {
+		it("should leave the builder image in place", func() {
+			_, _, err := dockerCli.ImageInspectWithRaw(context.TODO(), repoName)
+			h.AssertNil(t, err)
+			h.AssertEq(t, subject.BuilderImage, repoName)
+		})
+
+		it("should not create an ephemeral builder image", func() {
 			images, err := subject.Docker.ImageList(context.TODO(), dockertypes.ImageListOptions{})
 			h.AssertNil(t, err)
 
-			found := false
 			for _, image := range images {
 				for _, tag := range image.RepoTags {
-					if strings.Contains(tag, subject.BuilderImage) {
-						found = true
-						break
-					}
-				}
-				if found == true {
-					break
+					h.AssertNotContains(t, tag, "pack.local/builder/")
 				}
 			}
-			h.AssertEq(t, found, false)
 		})
 	})
 }
@@ -0,0 +1,118 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+// Order is the buildpack order for a single build. Groups are tried in turn, and each lists buildpacks either by the
+// ID of a buildpack in the builder or by the path to a local buildpack directory.
+type Order struct {
+	Groups []OrderGroup `toml:"groups"`
+}
+
+type OrderGroup struct {
+	Buildpacks []OrderBuildpack `toml:"buildpacks"`
+}
+
+type OrderBuildpack struct {
+	ID       string `toml:"id"`
+	Version  string `toml:"version"`
+	Path     string `toml:"path"`
+	Optional bool   `toml:"optional"`
+}
+
+// ReadOrder reads and validates an order.toml. Relative buildpack paths are resolved against the file's directory.
+func ReadOrder(path string) (Order, error) {
+	var order Order
+	md, err := toml.DecodeFile(path, &order)
+	if err != nil {
+		return Order{}, errors.Wrapf(err, "failed to read order file %s", style.Symbol(path))
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Order{}, fmt.Errorf("order file %s contains unknown key %s", style.Symbol(path), style.Symbol(undecoded[0].String()))
+	}
+
+	order.resolvePaths(filepath.Dir(path))
+	if err := order.Validate(); err != nil {
+		return Order{}, errors.Wrapf(err, "invalid order file %s", style.Symbol(path))
+	}
+	return order, nil
+}
+
+// ProjectDescriptorName is the file in the root of an app directory that may give the order for building the app, in
+// the same format as an order.toml but under the build table
+const ProjectDescriptorName = "project.toml"
+
+// ReadProjectOrder reads and validates the order of the project descriptor in appDir. It returns nil when there is no
+// project descriptor or it has no groups. Other tables of the project descriptor are ignored. Relative buildpack paths
+// are resolved against appDir.
+func ReadProjectOrder(appDir string) (*Order, error) {
+	path := filepath.Join(appDir, ProjectDescriptorName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var project struct {
+		Build Order `toml:"build"`
+	}
+	md, err := toml.DecodeFile(path, &project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read project descriptor %s", style.Symbol(path))
+	}
+	for _, key := range md.Undecoded() {
+		if len(key) > 0 && key[0] == "build" {
+			return nil, fmt.Errorf("project descriptor %s contains unknown key %s", style.Symbol(path), style.Symbol(key.String()))
+		}
+	}
+	if len(project.Build.Groups) == 0 {
+		return nil, nil
+	}
+
+	order := project.Build
+	order.resolvePaths(appDir)
+	if err := order.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid project descriptor %s", style.Symbol(path))
+	}
+	return &order, nil
+}
+
+func (o Order) resolvePaths(baseDir string) {
+	for _, group := range o.Groups {
+		for i, bp := range group.Buildpacks {
+			if bp.Path != "" && !filepath.IsAbs(bp.Path) {
+				group.Buildpacks[i].Path = filepath.Join(baseDir, bp.Path)
+			}
+		}
+	}
+}
+
+func (o Order) Validate() error {
+	if len(o.Groups) == 0 {
+		return errors.New("at least one group is required")
+	}
+	for i, group := range o.Groups {
+		if len(group.Buildpacks) == 0 {
+			return fmt.Errorf("group %d has no buildpacks", i+1)
+		}
+		for _, bp := range group.Buildpacks {
+			switch {
+			case bp.ID == "" && bp.Path == "":
+				return fmt.Errorf("group %d has a buildpack with neither an id nor a path", i+1)
+			case bp.ID != "" && bp.Path != "":
+				return fmt.Errorf("group %d has buildpack %s with both an id and a path", i+1, style.Symbol(bp.ID))
+			case bp.Path != "":
+				if _, err := os.Stat(filepath.Join(bp.Path, "buildpack.toml")); err != nil {
+					return fmt.Errorf("group %d has buildpack path %s without a buildpack.toml", i+1, style.Symbol(bp.Path))
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package build_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/build"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestOrder(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "order", testOrder, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testOrder(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "order-test")
+		h.AssertNil(t, err)
+		h.AssertNil(t, os.MkdirAll(filepath.Join(tmpDir, "local-bp"), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "local-bp", "buildpack.toml"), []byte(`[buildpack]
+id = "local.bp"
+version = "0.0.1"
+`), 0644))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	writeOrder := func(contents string) string {
+		path := filepath.Join(tmpDir, "order.toml")
+		h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+
+	when("#ReadOrder", func() {
+		it("reads groups mixing builder IDs and local directories", func() {
+			order, err := build.ReadOrder(writeOrder(`
+[[groups]]
+  [[groups.buildpacks]]
+    path = "local-bp"
+
+  [[groups.buildpacks]]
+    id = "some.bp"
+    version = "1.2.3"
+    optional = true
+
+[[groups]]
+  [[groups.buildpacks]]
+    id = "fallback.bp"
+`))
+			h.AssertNil(t, err)
+			h.AssertEq(t, order, build.Order{Groups: []build.OrderGroup{
+				{Buildpacks: []build.OrderBuildpack{
+					{Path: filepath.Join(tmpDir, "local-bp")},
+					{ID: "some.bp", Version: "1.2.3", Optional: true},
+				}},
+				{Buildpacks: []build.OrderBuildpack{
+					{ID: "fallback.bp"},
+				}},
+			}})
+		})
+
+		it("returns an error when there are no groups", func() {
+			_, err := build.ReadOrder(writeOrder(``))
+			h.AssertError(t, err, "at least one group is required")
+		})
+
+		it("returns an error for an empty group", func() {
+			_, err := build.ReadOrder(writeOrder(`
+[[groups]]
+  buildpacks = []
+`))
+			h.AssertError(t, err, "group 1 has no buildpacks")
+		})
+
+		it("returns an error for a buildpack with both an id and a path", func() {
+			_, err := build.ReadOrder(writeOrder(`
+[[groups]]
+  [[groups.buildpacks]]
+    id = "some.bp"
+    path = "local-bp"
+`))
+			h.AssertError(t, err, "group 1 has buildpack 'some.bp' with both an id and a path")
+		})
+
+		it("returns an error for a path that is not a buildpack", func() {
+			_, err := build.ReadOrder(writeOrder(`
+[[groups]]
+  [[groups.buildpacks]]
+    path = "missing-bp"
+`))
+			h.AssertError(t, err, "without a buildpack.toml")
+		})
+
+		it("returns an error for unknown keys", func() {
+			_, err := build.ReadOrder(writeOrder(`
+[[groups]]
+  [[groups.buildpacks]]
+    id = "some.bp"
+    optinal = true
+`))
+			h.AssertError(t, err, "unknown key 'groups.buildpacks.optinal'")
+		})
+	})
+
+	when("#ReadProjectOrder", func() {
+		writeProject := func(contents string) {
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "project.toml"), []byte(contents), 0644))
+		}
+
+		it("reads the groups of the build table", func() {
+			writeProject(`
+[project]
+  name = "some-app"
+
+[[build.groups]]
+  [[build.groups.buildpacks]]
+    path = "local-bp"
+    optional = true
+
+[[build.groups]]
+  [[build.groups.buildpacks]]
+    id = "fallback.bp"
+    version = "1.2.3"
+`)
+			order, err := build.ReadProjectOrder(tmpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, order, &build.Order{Groups: []build.OrderGroup{
+				{Buildpacks: []build.OrderBuildpack{
+					{Path: filepath.Join(tmpDir, "local-bp"), Optional: true},
+				}},
+				{Buildpacks: []build.OrderBuildpack{
+					{ID: "fallback.bp", Version: "1.2.3"},
+				}},
+			}})
+		})
+
+		it("returns no order when there is no project descriptor", func() {
+			order, err := build.ReadProjectOrder(tmpDir)
+			h.AssertNil(t, err)
+			h.AssertNil(t, order)
+		})
+
+		it("returns no order when the project descriptor has no groups", func() {
+			writeProject(`
+[project]
+  name = "some-app"
+`)
+			order, err := build.ReadProjectOrder(tmpDir)
+			h.AssertNil(t, err)
+			h.AssertNil(t, order)
+		})
+
+		it("validates the order", func() {
+			writeProject(`
+[[build.groups]]
+  [[build.groups.buildpacks]]
+    path = "missing-bp"
+`)
+			_, err := build.ReadProjectOrder(tmpDir)
+			h.AssertError(t, err, "invalid project descriptor")
+			h.AssertError(t, err, "without a buildpack.toml")
+		})
+
+		it("returns an error for unknown keys in the build table", func() {
+			writeProject(`
+[[build.groups]]
+  [[build.groups.buildpacks]]
+    id = "some.bp"
+    optinal = true
+`)
+			_, err := build.ReadProjectOrder(tmpDir)
+			h.AssertError(t, err, "unknown key 'build.groups.buildpacks.optinal'")
+		})
+	})
+}
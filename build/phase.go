@@ -3,6 +3,8 @@ package build
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"sync"
 
 	"github.com/buildpack/lifecycle/image/auth"
@@ -26,6 +28,13 @@ type Phase struct {
 	uid, gid int
 	appDir   string
 	appOnce  *sync.Once
+	inputs   []string
+	// stdout and stderr replace the default phase-prefixed output when set
+	stdout, stderr io.Writer
+	// steps, when set, are run one by one in the phase container instead of its command
+	steps []creatorStep
+	// privilegedEnv is added to the environment of privileged steps
+	privilegedEnv []string
 }
 
 func (l *Lifecycle) NewPhase(name string, ops ...func(*Phase) (*Phase, error)) (*Phase, error) {
@@ -39,7 +48,10 @@ func (l *Lifecycle) NewPhase(name string, ops ...func(*Phase) (*Phase, error)) (
 			fmt.Sprintf("%s:%s:", l.AppVolume, appDir),
 		},
 	}
-	ctrConf.Cmd = []string{"/lifecycle/" + name}
+	if l.LifecycleVolume != "" {
+		hostConf.Binds = append(hostConf.Binds, fmt.Sprintf("%s:%s:ro", l.LifecycleVolume, lifecycleDir))
+	}
+	ctrConf.Cmd = []string{lifecycleDir + "/" + name}
 	phase := &Phase{
 		ctrConf:  ctrConf,
 		hostConf: hostConf,
@@ -50,6 +62,7 @@ func (l *Lifecycle) NewPhase(name string, ops ...func(*Phase) (*Phase, error)) (
 		gid:      l.gid,
 		appDir:   l.appDir,
 		appOnce:  l.appOnce,
+		inputs:   l.inputs,
 	}
 	var err error
 	for _, op := range ops {
@@ -78,16 +91,33 @@ func WithDaemonAccess() func(*Phase) (*Phase, error) {
 
 func WithRegistryAccess(repos ...string) func(*Phase) (*Phase, error) {
 	return func(phase *Phase) (*Phase, error) {
-		authHeader, err := auth.BuildEnvVar(authn.DefaultKeychain, repos...)
+		authEnv, err := registryAuthEnv(repos...)
 		if err != nil {
 			return nil, err
 		}
-		phase.ctrConf.Env = []string{fmt.Sprintf(`CNB_REGISTRY_AUTH=%s`, authHeader)}
+		phase.ctrConf.Env = []string{authEnv}
 		phase.hostConf.NetworkMode = "host"
 		return phase, nil
 	}
 }
 
+func registryAuthEnv(repos ...string) (string, error) {
+	authHeader, err := auth.BuildEnvVar(authn.DefaultKeychain, repos...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`CNB_REGISTRY_AUTH=%s`, authHeader), nil
+}
+
+// WithOutput writes the output of the phase to stdout and stderr instead of the logger
+func WithOutput(stdout, stderr io.Writer) func(*Phase) (*Phase, error) {
+	return func(phase *Phase) (*Phase, error) {
+		phase.stdout = stdout
+		phase.stderr = stderr
+		return phase, nil
+	}
+}
+
 func (p *Phase) Run(context context.Context) error {
 	var err error
 	p.ctr, err = p.docker.ContainerCreate(context, p.ctrConf, p.hostConf, nil, "")
@@ -103,12 +133,41 @@ func (p *Phase) Run(context context.Context) error {
 	if err != nil {
 		return errors.Wrapf(err, "run %s container", p.name)
 	}
-	return p.docker.RunContainer(
-		context,
-		p.ctr.ID,
-		p.logger.VerboseWriter().WithPrefix(p.name),
-		p.logger.VerboseErrorWriter().WithPrefix(p.name),
-	)
+	for _, input := range p.inputs {
+		if err := p.copyInput(context, input); err != nil {
+			return errors.Wrapf(err, "failed to copy build inputs to '%s' container", p.name)
+		}
+	}
+	var stdout, stderr io.Writer = p.logger.VerboseWriter().WithPrefix(p.name), p.logger.VerboseErrorWriter().WithPrefix(p.name)
+	if p.stdout != nil {
+		stdout = p.stdout
+	}
+	if p.stderr != nil {
+		stderr = p.stderr
+	}
+	if len(p.steps) > 0 {
+		return p.runSteps(context, stdout, stderr)
+	}
+	return p.docker.RunContainer(context, p.ctr.ID, stdout, stderr)
+}
+
+func (p *Phase) copyInput(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.docker.CopyToContainer(ctx, p.ctr.ID, "/", f, types.CopyToContainerOptions{})
+}
+
+// Layers returns a tar of the layers directory as the phase left it, with entries under "layers/". The phase must
+// have run and not been cleaned up.
+func (p *Phase) Layers(ctx context.Context) (io.ReadCloser, error) {
+	rc, _, err := p.docker.CopyFromContainer(ctx, p.ctr.ID, layersDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to copy layers from '%s' container", p.name)
+	}
+	return rc, nil
 }
 
 func (p *Phase) Cleanup() error {
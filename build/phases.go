@@ -1,5 +1,9 @@
 package build
 
+import (
+	"sort"
+)
+
 const (
 	layersDir     = "/layers"
 	buildpacksDir = "/buildpacks"
@@ -10,16 +14,41 @@ const (
 	appDir        = "/workspace"
 )
 
-func (l *Lifecycle) NewDetect() (*Phase, error) {
-	 return l.NewPhase(
+// DefaultPlatformAPI is assumed for builders that do not declare the platform API of their lifecycle
+const DefaultPlatformAPI = "0.1"
+
+// phaseArgs provides the arguments each lifecycle phase binary expects for one platform API version
+type phaseArgs interface {
+	detect() []string
+	restore(cacheImage string) []string
+	analyze(repoName string, publish bool) []string
+	build() []string
+	export(repoName, runImage string, publish bool) []string
+	cache(cacheImage string) []string
+}
+
+var platformAPIs = map[string]phaseArgs{
+	"0.1": platformAPIv01{},
+}
+
+func SupportsPlatformAPI(version string) bool {
+	_, ok := platformAPIs[version]
+	return ok
+}
+
+func SupportedPlatformAPIs() []string {
+	var versions []string
+	for v := range platformAPIs {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func (l *Lifecycle) NewDetect(ops ...func(*Phase) (*Phase, error)) (*Phase, error) {
+	return l.NewPhase(
 		"detector",
-		WithArgs(
-			"-buildpacks", buildpacksDir,
-			"-order", orderPath,
-			"-group", groupPath,
-			"-plan", planPath,
-			"-app", appDir,
-		),
+		append([]func(*Phase) (*Phase, error){WithArgs(l.args.detect()...)}, ops...)...,
 	)
 }
 
@@ -27,11 +56,7 @@ func (l *Lifecycle) NewRestore(cacheImage string) (*Phase, error) {
 	return l.NewPhase(
 		"restorer",
 		WithDaemonAccess(),
-		WithArgs(
-			"-image", cacheImage,
-			"-group", groupPath,
-			"-layers", layersDir,
-		),
+		WithArgs(l.args.restore(cacheImage)...),
 	)
 }
 
@@ -40,37 +65,21 @@ func (l *Lifecycle) NewAnalyze(repoName string, publish bool) (*Phase, error) {
 		return l.NewPhase(
 			"analyzer",
 			WithRegistryAccess(repoName),
-			WithArgs(
-				"-layers", layersDir,
-				"-group", groupPath,
-				repoName,
-			),
+			WithArgs(l.args.analyze(repoName, publish)...),
 		)
 	} else {
 		return l.NewPhase(
 			"analyzer",
 			WithDaemonAccess(),
-			WithArgs(
-				"-layers", layersDir,
-				"-group", groupPath,
-				"-daemon",
-				repoName,
-			),
+			WithArgs(l.args.analyze(repoName, publish)...),
 		)
 	}
 }
 
-func (l *Lifecycle) NewBuild() (*Phase, error) {
+func (l *Lifecycle) NewBuild(ops ...func(*Phase) (*Phase, error)) (*Phase, error) {
 	return l.NewPhase(
 		"builder",
-		WithArgs(
-			"-buildpacks", buildpacksDir,
-			"-layers", layersDir,
-			"-app", appDir,
-			"-group", groupPath,
-			"-plan", planPath,
-			"-platform", platformDir,
-		),
+		append([]func(*Phase) (*Phase, error){WithArgs(l.args.build()...)}, ops...)...,
 	)
 }
 
@@ -79,39 +88,84 @@ func (l *Lifecycle) NewExport(repoName, runImage string, publish bool) (*Phase,
 		return l.NewPhase(
 			"exporter",
 			WithRegistryAccess(repoName, runImage),
-			WithArgs(
-				"-image", runImage,
-				"-layers", layersDir,
-				"-app", appDir,
-				"-group", groupPath,
-				repoName,
-			),
+			WithArgs(l.args.export(repoName, runImage, publish)...),
 		)
 	} else {
 		return l.NewPhase(
 			"exporter",
 			WithDaemonAccess(),
-			WithArgs(
-				"-image", runImage,
-				"-layers", layersDir,
-				"-app", appDir,
-				"-group", groupPath,
-				"-daemon",
-				repoName,
-			),
+			WithArgs(l.args.export(repoName, runImage, publish)...),
 		)
 	}
 }
 
-
 func (l *Lifecycle) NewCache(cacheImage string) (*Phase, error) {
 	return l.NewPhase(
 		"cacher",
 		WithDaemonAccess(),
-		WithArgs(
-			"-image", cacheImage,
-			"-group", groupPath,
-			"-layers", layersDir,
-		),
+		WithArgs(l.args.cache(cacheImage)...),
 	)
 }
+
+type platformAPIv01 struct{}
+
+func (platformAPIv01) detect() []string {
+	return []string{
+		"-buildpacks", buildpacksDir,
+		"-order", orderPath,
+		"-group", groupPath,
+		"-plan", planPath,
+		"-app", appDir,
+	}
+}
+
+func (platformAPIv01) restore(cacheImage string) []string {
+	return []string{
+		"-image", cacheImage,
+		"-group", groupPath,
+		"-layers", layersDir,
+	}
+}
+
+func (platformAPIv01) analyze(repoName string, publish bool) []string {
+	args := []string{
+		"-layers", layersDir,
+		"-group", groupPath,
+	}
+	if !publish {
+		args = append(args, "-daemon")
+	}
+	return append(args, repoName)
+}
+
+func (platformAPIv01) build() []string {
+	return []string{
+		"-buildpacks", buildpacksDir,
+		"-layers", layersDir,
+		"-app", appDir,
+		"-group", groupPath,
+		"-plan", planPath,
+		"-platform", platformDir,
+	}
+}
+
+func (platformAPIv01) export(repoName, runImage string, publish bool) []string {
+	args := []string{
+		"-image", runImage,
+		"-layers", layersDir,
+		"-app", appDir,
+		"-group", groupPath,
+	}
+	if !publish {
+		args = append(args, "-daemon")
+	}
+	return append(args, repoName)
+}
+
+func (platformAPIv01) cache(cacheImage string) []string {
+	return []string{
+		"-image", cacheImage,
+		"-group", groupPath,
+		"-layers", layersDir,
+	}
+}
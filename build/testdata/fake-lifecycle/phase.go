@@ -17,6 +17,10 @@ import (
 func main() {
 	fmt.Println("running some-lifecycle-phase")
 	fmt.Printf("received args %+v\n", os.Args)
+	fmt.Printf("running as uid %d\n", os.Getuid())
+	if _, err := os.Stat("/pack-daemon/docker.sock"); err == nil {
+		fmt.Println("daemon socket reachable")
+	}
 	if len(os.Args) > 3 && os.Args[1] == "write" {
 		testWrite(os.Args[2], os.Args[3])
 	}
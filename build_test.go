@@ -3,9 +3,13 @@ package pack_test
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -15,10 +19,12 @@ import (
 	"github.com/buildpack/pack/logging"
 
 	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega/ghttp"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/mocks"
 	h "github.com/buildpack/pack/testhelpers"
 )
@@ -205,6 +211,7 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("allows run-image from flags if the stacks match", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
@@ -222,6 +229,275 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, config.Builder, "some/builder")
 		})
 
+		it("enables single container mode from flags", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockRunImage, nil)
+
+			config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName:        "some/app",
+				Builder:         "some/builder",
+				NoPull:          true,
+				Publish:         true,
+				SingleContainer: true,
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, config.SingleContainer, true)
+		})
+
+		it("uses a persistent app volume keyed by app dir when reusing the app volume", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+
+			config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				AppDir:         "acceptance/testdata/node_app",
+				RepoName:       "some/app",
+				Builder:        "some/builder",
+				NoPull:         true,
+				ReuseAppVolume: true,
+			})
+			h.AssertNil(t, err)
+
+			absAppDir, _ := filepath.Abs("acceptance/testdata/node_app")
+			volume := fmt.Sprintf("pack-app-%x", md5.Sum([]byte(absAppDir)))
+			h.AssertEq(t, config.LifecycleConfig.AppVolume, volume)
+			h.AssertEq(t, filepath.Base(config.LifecycleConfig.AppVolumeManifest), volume+".json")
+			h.AssertEq(t, filepath.Base(filepath.Dir(config.LifecycleConfig.AppVolumeManifest)), "app-volumes")
+		})
+
+		it("returns an error when both buildpacks and an order are given", func() {
+			_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName:   "some/app",
+				Builder:    "some/builder",
+				Buildpacks: []string{"some.bp"},
+				Order:      "some/order.toml",
+			})
+			h.AssertError(t, err, "--buildpack and --order cannot be used together")
+		})
+
+		it("validates the order before fetching any images", func() {
+			_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName: "some/app",
+				Builder:  "some/builder",
+				Order:    "some/missing/order.toml",
+			})
+			h.AssertError(t, err, "failed to read order file 'some/missing/order.toml'")
+		})
+
+		it("reads the order from the project descriptor of the app", func() {
+			appDir, err := ioutil.TempDir("", "build-project-descriptor")
+			h.AssertNil(t, err)
+			defer os.RemoveAll(appDir)
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "project.toml"), []byte(`
+[[build.groups]]
+  [[build.groups.buildpacks]]
+    path = "missing-bp"
+`), 0644))
+
+			_, err = factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				AppDir:   appDir,
+				RepoName: "some/app",
+				Builder:  "some/builder",
+			})
+			h.AssertError(t, err, "invalid project descriptor")
+		})
+
+		it("returns an error for a buildpack that is not in the builder before fetching the run image", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"buildpacks": [{"id": "some.bp", "version": "1.0.0", "latest": true}], "stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+			mockBuilderImage.EXPECT().Name().Return("some/builder").AnyTimes()
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+			_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName:   "some/app",
+				Builder:    "some/builder",
+				NoPull:     true,
+				Buildpacks: []string{"some.bq@1.0.0"},
+			})
+			h.AssertError(t, err, "invalid buildpack for builder 'some/builder': buildpack 'some.bq' not found (did you mean 'some.bp'?)")
+		})
+
+		it("accepts buildpacks that are in the builder", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"buildpacks": [{"id": "some.bp", "version": "1.0.0", "latest": true}], "stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+
+			_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName:   "some/app",
+				Builder:    "some/builder",
+				NoPull:     true,
+				Buildpacks: []string{"some.bp", "some.bp@1.0.0"},
+			})
+			h.AssertNil(t, err)
+		})
+
+		it("does not validate buildpacks when the builder has no metadata", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("", nil).AnyTimes()
+			mockBuilderImage.EXPECT().Name().Return("some/builder").AnyTimes()
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+
+			_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName:   "some/app",
+				Builder:    "some/builder",
+				RunImage:   "some/run",
+				NoPull:     true,
+				Buildpacks: []string{"some.bp@1.0.0"},
+			})
+			h.AssertNil(t, err)
+		})
+
+		when("buildpacks are given as archives or URLs", func() {
+			var (
+				mockBuilderImage *mocks.MockImage
+				cacheDir         string
+			)
+
+			it.Before(func() {
+				var err error
+				cacheDir, err = ioutil.TempDir("", "build-factory-dl-cache")
+				h.AssertNil(t, err)
+				factory.BuildpackFetcher = buildpack.NewFetcher(logger, cacheDir)
+
+				mockBuilderImage = mocks.NewMockImage(mockController)
+				mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+
+				mockRunImage := mocks.NewMockImage(mockController)
+				mockRunImage.EXPECT().Found().Return(true, nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.RemoveAll(cacheDir))
+			})
+
+			it("extracts a local .tgz into a buildpack directory", func() {
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName:   "some/app",
+					Builder:    "some/builder",
+					NoPull:     true,
+					Buildpacks: []string{filepath.Join("buildpack", "testdata", "buildpack.tgz")},
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(config.LifecycleConfig.Buildpacks), 1)
+				h.AssertDirContainsFileWithContents(t, config.LifecycleConfig.Buildpacks[0], "bin/detect", "I come from an archive\n")
+			})
+
+			it("downloads a buildpack from a URL into the download cache", func() {
+				server := ghttp.NewServer()
+				server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+					http.ServeFile(w, r, filepath.Join("buildpack", "testdata", "buildpack.tgz"))
+				})
+				defer server.Close()
+
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName:   "some/app",
+					Builder:    "some/builder",
+					NoPull:     true,
+					Buildpacks: []string{server.URL() + "/buildpack.tgz"},
+				})
+				h.AssertNil(t, err)
+				h.AssertContains(t, config.LifecycleConfig.Buildpacks[0], filepath.Join(cacheDir, "dl-cache"))
+				h.AssertDirContainsFileWithContents(t, config.LifecycleConfig.Buildpacks[0], "bin/build", "I come from an archive\n")
+			})
+
+			it("verifies a sha256 checksum given in the URL fragment", func() {
+				server := ghttp.NewServer()
+				server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+					http.ServeFile(w, r, filepath.Join("buildpack", "testdata", "buildpack.tgz"))
+				})
+				defer server.Close()
+
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName:   "some/app",
+					Builder:    "some/builder",
+					NoPull:     true,
+					Buildpacks: []string{server.URL() + "/buildpack.tgz#sha256=aac760bcab0232476bb98920f597e443f56d2de247026f1de99f0ddee7f499df"},
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, config.LifecycleConfig.Buildpacks[0], "bin/build", "I come from an archive\n")
+			})
+		})
+
+		when("a lifecycle image is provided", func() {
+			var mockBuilderImage, mockRunImage *mocks.MockImage
+
+			it.Before(func() {
+				mockBuilderImage = mocks.NewMockImage(mockController)
+				mockRunImage = mocks.NewMockImage(mockController)
+				mockRunImage.EXPECT().Found().Return(true, nil)
+			})
+
+			it("pulls the lifecycle image from flags", func() {
+				mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
+				mockLifecycleImage := mocks.NewMockImage(mockController)
+				mockLifecycleImage.EXPECT().Found().Return(true, nil)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/lifecycle", gomock.Any()).Return(mockLifecycleImage, nil)
+
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName:       "some/app",
+					Builder:        "some/builder",
+					LifecycleImage: "some/lifecycle",
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, config.LifecycleConfig.LifecycleImage, "some/lifecycle")
+			})
+
+			it("uses the lifecycle image declared by the builder", func() {
+				mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}, "lifecycle": {"image": "builder/lifecycle"}}`, nil).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+				mockLifecycleImage := mocks.NewMockImage(mockController)
+				mockLifecycleImage.EXPECT().Found().Return(true, nil)
+				mockFetcher.EXPECT().FetchLocalImage("builder/lifecycle").Return(mockLifecycleImage, nil)
+
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName: "some/app",
+					Builder:  "some/builder",
+					NoPull:   true,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, config.LifecycleConfig.LifecycleImage, "builder/lifecycle")
+			})
+
+			it("returns an error if the lifecycle image doesn't exist", func() {
+				mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockBuilderImage, nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
+				mockLifecycleImage := mocks.NewMockImage(mockController)
+				mockLifecycleImage.EXPECT().Found().Return(false, nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/lifecycle").Return(mockLifecycleImage, nil)
+
+				_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName:       "some/app",
+					Builder:        "some/builder",
+					NoPull:         true,
+					LifecycleImage: "some/lifecycle",
+				})
+				h.AssertError(t, err, "lifecycle image 'some/lifecycle' does not exist")
+			})
+		})
+
 		it("uses working dir if appDir is set to placeholder value", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
@@ -400,5 +676,26 @@ PATH
 			})
 			h.AssertNotEq(t, os.Getenv("PATH"), "")
 		})
+
+		it("adds the builder's build env beneath user env", func() {
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}, "buildEnv": {"VAR1": "builder1", "HTTP_PROXY": "http://proxy.example.com"}}`, nil).AnyTimes()
+			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
+
+			config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+				RepoName: "some/app",
+				Builder:  "some/builder",
+				Env:      []string{"VAR1=override1"},
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, config.LifecycleConfig.Env, map[string]string{
+				"VAR1":       "override1",
+				"HTTP_PROXY": "http://proxy.example.com",
+			})
+		})
 	}, spec.Parallel())
 }
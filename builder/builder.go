@@ -55,6 +55,42 @@ func (b *Builder) GetMetadata() (*Metadata, error) {
 	return &metadata, nil
 }
 
+// GetLifecycleImage returns the image the builder declares as the source of its lifecycle, if any.
+// Builders without a metadata label simply use the lifecycle they contain.
+func (b *Builder) GetLifecycleImage() (string, error) {
+	metadata, err := b.GetOptionalMetadata()
+	if err != nil || metadata == nil {
+		return "", err
+	}
+	return metadata.Lifecycle.Image, nil
+}
+
+// GetBuildEnv returns the default build-time environment variables the builder declares, if any
+func (b *Builder) GetBuildEnv() (map[string]string, error) {
+	metadata, err := b.GetOptionalMetadata()
+	if err != nil || metadata == nil {
+		return nil, err
+	}
+	return metadata.BuildEnv, nil
+}
+
+// GetOptionalMetadata is like GetMetadata, but returns nil for builders without a metadata label
+func (b *Builder) GetOptionalMetadata() (*Metadata, error) {
+	label, err := b.image.Label(MetadataLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find metadata for builder %s", style.Symbol(b.image.Name()))
+	}
+	if label == "" {
+		return nil, nil
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse metadata for builder %s", style.Symbol(b.image.Name()))
+	}
+	return &metadata, nil
+}
+
 func (b *Builder) GetLocalRunImageMirrors() ([]string, error) {
 	metadata, err := b.GetMetadata()
 	if err != nil {
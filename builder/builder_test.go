@@ -95,6 +95,49 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#GetLifecycleImage", func() {
+		it("returns the lifecycle image from the metadata", func() {
+			mockImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"lifecycle": {"image": "some/lifecycle"}}`, nil)
+
+			image, err := subject.GetLifecycleImage()
+			h.AssertNil(t, err)
+			h.AssertEq(t, image, "some/lifecycle")
+		})
+
+		it("returns an empty string when the metadata label is missing", func() {
+			mockImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("", nil)
+
+			image, err := subject.GetLifecycleImage()
+			h.AssertNil(t, err)
+			h.AssertEq(t, image, "")
+		})
+
+		it("returns an error when the metadata label is not parsable", func() {
+			mockImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("junk", nil)
+
+			_, err := subject.GetLifecycleImage()
+			h.AssertError(t, err, "failed to parse metadata for builder 'some/builder'")
+		})
+	})
+
+	when("#GetBuildEnv", func() {
+		it("returns the build env from the metadata", func() {
+			mockImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"buildEnv": {"HTTP_PROXY": "http://proxy.example.com"}}`, nil)
+
+			env, err := subject.GetBuildEnv()
+			h.AssertNil(t, err)
+			h.AssertEq(t, env, map[string]string{"HTTP_PROXY": "http://proxy.example.com"})
+		})
+
+		it("returns no env when the metadata label is missing", func() {
+			mockImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("", nil)
+
+			env, err := subject.GetBuildEnv()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(env), 0)
+		})
+	})
+
 	when("#GetLocalRunImageMirrors", func() {
 		when("run image exists in config", func() {
 			it.Before(func() {
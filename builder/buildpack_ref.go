@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buildpack/pack/style"
+)
+
+// FindBuildpack resolves a buildpack ID and version against the buildpacks in the builder. An empty version or
+// "latest" resolves to the single version flagged as latest.
+func (m *Metadata) FindBuildpack(id, version string) (BuildpackMetadata, error) {
+	var (
+		versions []string
+		latest   []BuildpackMetadata
+	)
+	for _, bp := range m.Buildpacks {
+		if bp.ID != id {
+			continue
+		}
+		if bp.Version == version {
+			return bp, nil
+		}
+		versions = append(versions, bp.Version)
+		if bp.Latest {
+			latest = append(latest, bp)
+		}
+	}
+	sort.Strings(versions)
+
+	if len(versions) == 0 {
+		if suggestions := m.similarBuildpackIDs(id); len(suggestions) > 0 {
+			return BuildpackMetadata{}, fmt.Errorf("buildpack %s not found (did you mean %s?)", style.Symbol(id), strings.Join(suggestions, ", "))
+		}
+		return BuildpackMetadata{}, fmt.Errorf("buildpack %s not found", style.Symbol(id))
+	}
+
+	if version != "" && version != "latest" {
+		return BuildpackMetadata{}, fmt.Errorf("version %s of buildpack %s not found (available versions: %s)", style.Symbol(version), style.Symbol(id), strings.Join(versions, ", "))
+	}
+
+	switch len(latest) {
+	case 1:
+		return latest[0], nil
+	case 0:
+		return BuildpackMetadata{}, fmt.Errorf("buildpack %s has no version marked latest (available versions: %s) -- specify one with %s", style.Symbol(id), strings.Join(versions, ", "), style.Symbol(id+"@<version>"))
+	default:
+		var latestVersions []string
+		for _, bp := range latest {
+			latestVersions = append(latestVersions, bp.Version)
+		}
+		sort.Strings(latestVersions)
+		return BuildpackMetadata{}, fmt.Errorf("buildpack %s has more than one version marked latest (%s) -- specify one with %s", style.Symbol(id), strings.Join(latestVersions, ", "), style.Symbol(id+"@<version>"))
+	}
+}
+
+// similarBuildpackIDs returns the buildpack IDs in the builder that are likely to be what was meant by id: the closest
+// ones by edit distance, and any that end with it
+func (m *Metadata) similarBuildpackIDs(id string) []string {
+	maxDistance := len(id) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var closest, suffixed []string
+	seen := map[string]bool{}
+	for _, bp := range m.Buildpacks {
+		if seen[bp.ID] {
+			continue
+		}
+		seen[bp.ID] = true
+
+		if strings.HasSuffix(bp.ID, "/"+id) || strings.HasSuffix(bp.ID, "."+id) {
+			suffixed = append(suffixed, style.Symbol(bp.ID))
+			continue
+		}
+		distance := editDistance(strings.ToLower(id), strings.ToLower(bp.ID))
+		switch {
+		case distance < maxDistance:
+			maxDistance = distance
+			closest = []string{style.Symbol(bp.ID)}
+		case distance == maxDistance:
+			closest = append(closest, style.Symbol(bp.ID))
+		}
+	}
+
+	similar := append(closest, suffixed...)
+	sort.Strings(similar)
+	return similar
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
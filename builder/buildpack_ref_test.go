@@ -0,0 +1,78 @@
+package builder_test
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/builder"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestBuildpackRef(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "BuildpackRef", testBuildpackRef, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackRef(t *testing.T, when spec.G, it spec.S) {
+	var metadata *builder.Metadata
+
+	it.Before(func() {
+		metadata = &builder.Metadata{
+			Buildpacks: []builder.BuildpackMetadata{
+				{ID: "io.buildpacks.samples.nodejs", Version: "0.0.1", Latest: false},
+				{ID: "io.buildpacks.samples.nodejs", Version: "0.0.2", Latest: true},
+				{ID: "io.buildpacks.samples.java", Version: "1.0.0", Latest: false},
+				{ID: "io.buildpacks.samples.go", Version: "1.0.0", Latest: true},
+				{ID: "io.buildpacks.samples.go", Version: "2.0.0", Latest: true},
+			},
+		}
+	})
+
+	when("#FindBuildpack", func() {
+		it("finds an exact version", func() {
+			bp, err := metadata.FindBuildpack("io.buildpacks.samples.nodejs", "0.0.1")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Version, "0.0.1")
+		})
+
+		it("resolves a missing version to the latest", func() {
+			bp, err := metadata.FindBuildpack("io.buildpacks.samples.nodejs", "")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Version, "0.0.2")
+		})
+
+		it("resolves 'latest' to the latest version", func() {
+			bp, err := metadata.FindBuildpack("io.buildpacks.samples.nodejs", "latest")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Version, "0.0.2")
+		})
+
+		it("suggests similar IDs for an unknown buildpack", func() {
+			_, err := metadata.FindBuildpack("io.buildpacks.samples.nodjs", "")
+			h.AssertError(t, err, "buildpack 'io.buildpacks.samples.nodjs' not found (did you mean 'io.buildpacks.samples.nodejs'?)")
+		})
+
+		it("suggests IDs ending with a short name", func() {
+			_, err := metadata.FindBuildpack("java", "")
+			h.AssertError(t, err, "buildpack 'java' not found (did you mean 'io.buildpacks.samples.java'?)")
+		})
+
+		it("lists available versions for an unknown version", func() {
+			_, err := metadata.FindBuildpack("io.buildpacks.samples.nodejs", "9.9.9")
+			h.AssertError(t, err, "version '9.9.9' of buildpack 'io.buildpacks.samples.nodejs' not found (available versions: 0.0.1, 0.0.2)")
+		})
+
+		it("returns an error when no version is marked latest", func() {
+			_, err := metadata.FindBuildpack("io.buildpacks.samples.java", "")
+			h.AssertError(t, err, "buildpack 'io.buildpacks.samples.java' has no version marked latest")
+		})
+
+		it("returns an error when the latest version is ambiguous", func() {
+			_, err := metadata.FindBuildpack("io.buildpacks.samples.go", "")
+			h.AssertError(t, err, "buildpack 'io.buildpacks.samples.go' has more than one version marked latest (1.0.0, 2.0.0)")
+		})
+	})
+}
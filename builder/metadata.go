@@ -10,9 +10,25 @@ import (
 const MetadataLabel = "io.buildpacks.builder.metadata"
 
 type TOML struct {
+	// Extends is the builder image, or the path of the builder.toml, that this builder is based on
+	Extends    string                     `toml:"extends"`
 	Buildpacks []buildpack.Buildpack      `toml:"buildpacks"`
 	Groups     []lifecycle.BuildpackGroup `toml:"groups"`
 	Stack      Stack
+	Lifecycle  Lifecycle `toml:"lifecycle"`
+	Builder    Info      `toml:"builder"`
+	Build      Build     `toml:"build"`
+}
+
+// Info describes a builder to its users
+type Info struct {
+	Description string `toml:"description"`
+	Maintainer  string `toml:"maintainer"`
+}
+
+type Build struct {
+	// Env holds default build-time environment variables, which users can override with --env
+	Env map[string]string `toml:"env"`
 }
 
 type Stack struct {
@@ -22,16 +38,33 @@ type Stack struct {
 	RunImageMirrors []string `toml:"run-image-mirrors,omitempty"`
 }
 
+type Lifecycle struct {
+	Version     string `toml:"version"`
+	PlatformAPI string `toml:"platform-api"`
+	Image       string `toml:"image"`
+}
+
 type Metadata struct {
-	Buildpacks []BuildpackMetadata `json:"buildpacks"`
-	Groups     []GroupMetadata     `json:"groups"`
-	Stack      stack.Metadata      `json:"stack"`
+	Buildpacks  []BuildpackMetadata `json:"buildpacks"`
+	Groups      []GroupMetadata     `json:"groups"`
+	Stack       stack.Metadata      `json:"stack"`
+	Lifecycle   LifecycleMetadata   `json:"lifecycle"`
+	Description string              `json:"description,omitempty"`
+	Maintainer  string              `json:"maintainer,omitempty"`
+	BuildEnv    map[string]string   `json:"buildEnv,omitempty"`
+}
+
+type LifecycleMetadata struct {
+	Version     string `json:"version,omitempty"`
+	PlatformAPI string `json:"platformApi"`
+	Image       string `json:"image,omitempty"`
 }
 
 type BuildpackMetadata struct {
-	ID      string `json:"id"`
-	Version string `json:"version"`
-	Latest  bool   `json:"latest"`
+	ID      string   `json:"id"`
+	Version string   `json:"version"`
+	Latest  bool     `json:"latest"`
+	Stacks  []string `json:"stacks,omitempty"`
 }
 
 type GroupMetadata struct {
@@ -0,0 +1,64 @@
+package buildpack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+)
+
+// PackageArchive validates the buildpack in bpDir and writes it to archiveFile as a reproducible .tgz with
+// buildpack.toml at its root
+func PackageArchive(bpDir, archiveFile string) (Descriptor, error) {
+	desc, err := ReadDescriptor(bpDir)
+	if err != nil {
+		return Descriptor{}, err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(archiveFile), filepath.Base(archiveFile)+".")
+	if err != nil {
+		return Descriptor{}, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// the archive may be written into bpDir itself, so leave out its previous version and temp files
+	output, err := relativeOutputPath(bpDir, archiveFile)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	include := func(p string) bool {
+		return output == "" || (p != output && !strings.HasPrefix(p, output+"."))
+	}
+	if err := archive.CreateFilteredTarGZ(tmpFile.Name(), bpDir, 0, 0, include); err != nil {
+		return Descriptor{}, errors.Wrapf(err, "failed to write archive %s", archiveFile)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return Descriptor{}, err
+	}
+	if err := os.Rename(tmpFile.Name(), archiveFile); err != nil {
+		return Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// relativeOutputPath returns the slash-separated path of archiveFile relative to bpDir, or "" if it is outside bpDir
+func relativeOutputPath(bpDir, archiveFile string) (string, error) {
+	absDir, err := filepath.Abs(bpDir)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(archiveFile)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
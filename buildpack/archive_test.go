@@ -0,0 +1,177 @@
+package buildpack_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/buildpack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestBuildpackArchive(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("buildpack archives are not tested on windows")
+	}
+	spec.Run(t, "BuildpackArchive", testBuildpackArchive, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackArchive(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir, bpDir string
+
+	writeBuildpack := func(descriptor string, mode os.FileMode) {
+		h.AssertNil(t, os.MkdirAll(filepath.Join(bpDir, "bin"), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpDir, "buildpack.toml"), []byte(descriptor), 0644))
+		for _, name := range []string{"detect", "build"} {
+			path := filepath.Join(bpDir, "bin", name)
+			h.AssertNil(t, ioutil.WriteFile(path, []byte("#!/usr/bin/env bash\n"), mode))
+			h.AssertNil(t, os.Chmod(path, mode))
+		}
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "buildpack-archive")
+		h.AssertNil(t, err)
+		bpDir = filepath.Join(tmpDir, "buildpack")
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#PackageArchive", func() {
+		it("writes the buildpack with buildpack.toml at the root", func() {
+			archiveFile := filepath.Join(tmpDir, "buildpack.tgz")
+
+			desc, err := buildpack.PackageArchive(filepath.Join("testdata", "buildpack"), archiveFile)
+			h.AssertNil(t, err)
+			h.AssertEq(t, desc.Buildpack.ID, "some-buildpack-id")
+			h.AssertEq(t, desc.Stacks, []buildpack.DescriptorStack{{ID: "some.stack.id"}})
+
+			headers := readTgz(t, archiveFile)
+			h.AssertEq(t, len(headers), 4)
+			h.AssertEq(t, headers[0].Name, "bin")
+			h.AssertEq(t, headers[1].Name, "bin/build")
+			h.AssertEq(t, headers[2].Name, "bin/detect")
+			h.AssertEq(t, headers[3].Name, "buildpack.toml")
+			for _, header := range headers {
+				h.AssertEq(t, header.Uid, 0)
+				h.AssertEq(t, header.Gid, 0)
+				h.AssertEq(t, header.ModTime.UTC(), archive.NormalizedDateTime)
+			}
+		})
+
+		it("produces the same archive regardless of file modes and timestamps", func() {
+			descriptor := "[buildpack]\nid = \"some-id\"\nversion = \"1.0\"\n\n[[stacks]]\nid = \"some.stack.id\"\n"
+			writeBuildpack(descriptor, 0700)
+			first := filepath.Join(tmpDir, "first.tgz")
+			_, err := buildpack.PackageArchive(bpDir, first)
+			h.AssertNil(t, err)
+
+			h.AssertNil(t, os.RemoveAll(bpDir))
+			writeBuildpack(descriptor, 0775)
+			second := filepath.Join(tmpDir, "second.tgz")
+			_, err = buildpack.PackageArchive(bpDir, second)
+			h.AssertNil(t, err)
+
+			firstBytes, err := ioutil.ReadFile(first)
+			h.AssertNil(t, err)
+			secondBytes, err := ioutil.ReadFile(second)
+			h.AssertNil(t, err)
+			h.AssertEq(t, bytes.Equal(firstBytes, secondBytes), true)
+
+			for _, header := range readTgz(t, first) {
+				if header.Name == "buildpack.toml" {
+					h.AssertEq(t, header.Mode, int64(0644))
+				} else {
+					h.AssertEq(t, header.Mode, int64(0755))
+				}
+			}
+		})
+
+		it("produces the same archive when packaging into the buildpack directory", func() {
+			writeBuildpack("[buildpack]\nid = \"some-id\"\nversion = \"1.0\"\n\n[[stacks]]\nid = \"some.stack.id\"\n", 0755)
+			archiveFile := filepath.Join(bpDir, "buildpack.tgz")
+
+			_, err := buildpack.PackageArchive(bpDir, archiveFile)
+			h.AssertNil(t, err)
+			first, err := ioutil.ReadFile(archiveFile)
+			h.AssertNil(t, err)
+
+			_, err = buildpack.PackageArchive(bpDir, archiveFile)
+			h.AssertNil(t, err)
+			second, err := ioutil.ReadFile(archiveFile)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, sha256.Sum256(first), sha256.Sum256(second))
+			for _, header := range readTgz(t, archiveFile) {
+				h.AssertNotContains(t, header.Name, "buildpack.tgz")
+			}
+		})
+
+		it("does not write an archive for an invalid buildpack", func() {
+			writeBuildpack("[buildpack]\nid = \"some-id\"\nversion = \"1.0\"\n", 0755)
+			archiveFile := filepath.Join(tmpDir, "buildpack.tgz")
+
+			_, err := buildpack.PackageArchive(bpDir, archiveFile)
+			h.AssertError(t, err, "at least one '[[stacks]]' entry is required")
+
+			_, err = os.Stat(archiveFile)
+			h.AssertEq(t, os.IsNotExist(err), true)
+		})
+	})
+
+	when("#ReadDescriptor", func() {
+		for _, tc := range []struct {
+			name, descriptor, err string
+		}{
+			{"requires an id", "[buildpack]\nversion = \"1.0\"\n", "'buildpack.id' is required"},
+			{"requires a version", "[buildpack]\nid = \"some-id\"\n", "'buildpack.version' is required"},
+			{"requires stack ids", "[buildpack]\nid = \"some-id\"\nversion = \"1.0\"\n\n[[stacks]]\n", "'stacks.id' is required for stack 1"},
+		} {
+			tc := tc
+			it(tc.name, func() {
+				writeBuildpack(tc.descriptor, 0755)
+
+				_, err := buildpack.ReadDescriptor(bpDir)
+				h.AssertError(t, err, "invalid buildpack.toml in")
+				h.AssertError(t, err, tc.err)
+			})
+		}
+	})
+}
+
+func readTgz(t *testing.T, path string) []*tar.Header {
+	t.Helper()
+	file, err := os.Open(path)
+	h.AssertNil(t, err)
+	defer file.Close()
+	gzr, err := gzip.NewReader(file)
+	h.AssertNil(t, err)
+	defer gzr.Close()
+
+	var headers []*tar.Header
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return headers
+		}
+		h.AssertNil(t, err)
+		headers = append(headers, header)
+	}
+}
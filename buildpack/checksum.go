@@ -0,0 +1,45 @@
+package buildpack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const checksumFragment = "sha256="
+
+// splitChecksum separates a `#sha256=<hex>` fragment from a buildpack URI and reconciles it with the checksum given
+// alongside the URI. It returns the URI without the fragment and the expected checksum, if any.
+func splitChecksum(uri, sha string) (string, string, error) {
+	sha = strings.ToLower(sha)
+	if i := strings.LastIndex(uri, "#"); i >= 0 {
+		fragment := uri[i+1:]
+		if !strings.HasPrefix(fragment, checksumFragment) {
+			return "", "", fmt.Errorf("unsupported fragment %q in URI %q: only %q is supported", fragment, uri, checksumFragment+"<checksum>")
+		}
+		fromURI := strings.ToLower(strings.TrimPrefix(fragment, checksumFragment))
+		if sha != "" && sha != fromURI {
+			return "", "", fmt.Errorf("conflicting sha256 checksums for %q: %s and %s", uri[:i], sha, fromURI)
+		}
+		uri, sha = uri[:i], fromURI
+	}
+
+	if sha != "" {
+		if decoded, err := hex.DecodeString(sha); err != nil || len(decoded) != sha256.Size {
+			return "", "", fmt.Errorf("invalid sha256 checksum %q for %q", sha, uri)
+		}
+	}
+	return uri, sha, nil
+}
+
+func verifyFileChecksum(path, expected string) error {
+	digest, err := fileDigest(path)
+	if err != nil {
+		return err
+	}
+	if actual := strings.TrimPrefix(digest, "sha256:"); actual != expected {
+		return fmt.Errorf("sha256 checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package buildpack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+const buildpackTgzSHA256 = "aac760bcab0232476bb98920f597e443f56d2de247026f1de99f0ddee7f499df"
+
+func TestBuildpackChecksum(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("create builder is not implemented on windows")
+	}
+	spec.Run(t, "BuildpackChecksum", testBuildpackChecksum, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackChecksum(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cacheDir string
+		server   *ghttp.Server
+		subject  *buildpack.Fetcher
+	)
+
+	it.Before(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "buildpack-checksum")
+		h.AssertNil(t, err)
+		subject = buildpack.NewFetcher(&emptyLogger{}, cacheDir)
+
+		server = ghttp.NewServer()
+		server.AllowUnhandledRequests = true
+	})
+
+	it.After(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	serveArchive := func(etag string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if etag != "" && r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Etag", etag)
+			http.ServeFile(w, r, filepath.Join("testdata", "buildpack.tgz"))
+		}
+	}
+
+	when("#FetchBuildpack", func() {
+		it("accepts a download matching the sha256 field", func() {
+			server.AppendHandlers(serveArchive(""))
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz", SHA256: buildpackTgzSHA256})
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.URI, server.URL()+"/buildpack.tgz")
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+		})
+
+		it("accepts a download matching the sha256 URI fragment", func() {
+			server.AppendHandlers(serveArchive(""))
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz#sha256=" + buildpackTgzSHA256})
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.URI, server.URL()+"/buildpack.tgz")
+			h.AssertEq(t, out.SHA256, buildpackTgzSHA256)
+		})
+
+		it("rejects a download that does not match", func() {
+			server.AppendHandlers(serveArchive(`"some-etag"`))
+			wrongSHA := "0000000000000000000000000000000000000000000000000000000000000000"
+
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz", SHA256: wrongSHA})
+			h.AssertError(t, err, "sha256 checksum mismatch: expected "+wrongSHA+", got "+buildpackTgzSHA256)
+
+			etagFiles, err := filepath.Glob(filepath.Join(cacheDir, "dl-cache", "*.etag"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(etagFiles), 0)
+		})
+
+		it("re-verifies the cached archive when the server reports it unchanged", func() {
+			server.AppendHandlers(serveArchive(`"some-etag"`), serveArchive(`"some-etag"`), serveArchive(`"some-etag"`))
+			bp := buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz", SHA256: buildpackTgzSHA256}
+
+			out, err := subject.FetchBuildpack(".", bp)
+			h.AssertNil(t, err)
+
+			archives, err := filepath.Glob(filepath.Join(cacheDir, "dl-cache", "*.tgz"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(archives), 1)
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(out.Dir, "bin", "detect"), []byte("tampered"), 0755))
+
+			out, err = subject.FetchBuildpack(".", bp)
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+			h.AssertEq(t, server.ReceivedRequests()[1].Header.Get("If-None-Match"), `"some-etag"`)
+
+			h.AssertNil(t, ioutil.WriteFile(archives[0], []byte("tampered"), 0644))
+
+			out, err = subject.FetchBuildpack(".", bp)
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+			h.AssertEq(t, server.ReceivedRequests()[2].Header.Get("If-None-Match"), "")
+		})
+
+		it("verifies a local archive", func() {
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: filepath.Join("testdata", "buildpack.tgz") + "#sha256=" + buildpackTgzSHA256})
+			h.AssertNil(t, err)
+
+			_, err = subject.FetchBuildpack(".", buildpack.Buildpack{URI: filepath.Join("testdata", "buildpack.tgz"), SHA256: "1111111111111111111111111111111111111111111111111111111111111111"})
+			h.AssertError(t, err, "sha256 checksum mismatch")
+		})
+
+		it("rejects a checksum for a buildpack directory", func() {
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: filepath.Join("testdata", "buildpack"), SHA256: buildpackTgzSHA256})
+			h.AssertError(t, err, "sha256 checksums can only be verified for .tgz archives")
+		})
+
+		it("rejects malformed and conflicting checksums", func() {
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz#sha256=abc"})
+			h.AssertError(t, err, `invalid sha256 checksum "abc"`)
+
+			_, err = subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz#md5=abc"})
+			h.AssertError(t, err, `unsupported fragment "md5=abc"`)
+
+			_, err = subject.FetchBuildpack(".", buildpack.Buildpack{
+				URI:    server.URL() + "/buildpack.tgz#sha256=" + buildpackTgzSHA256,
+				SHA256: "1111111111111111111111111111111111111111111111111111111111111111",
+			})
+			h.AssertError(t, err, "conflicting sha256 checksums")
+		})
+	})
+}
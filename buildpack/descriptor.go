@@ -0,0 +1,58 @@
+package buildpack
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+// Descriptor is the content of a buildpack's buildpack.toml
+type Descriptor struct {
+	Buildpack DescriptorInfo    `toml:"buildpack"`
+	Stacks    []DescriptorStack `toml:"stacks"`
+}
+
+type DescriptorInfo struct {
+	ID      string `toml:"id"`
+	Version string `toml:"version"`
+	Name    string `toml:"name,omitempty"`
+}
+
+type DescriptorStack struct {
+	ID string `toml:"id"`
+}
+
+// ReadDescriptor reads and validates the buildpack.toml of the buildpack in bpDir
+func ReadDescriptor(bpDir string) (Descriptor, error) {
+	path := filepath.Join(bpDir, "buildpack.toml")
+	var desc Descriptor
+	if _, err := toml.DecodeFile(path, &desc); err != nil {
+		return Descriptor{}, errors.Wrapf(err, "reading buildpack.toml from buildpack %s", style.Symbol(bpDir))
+	}
+	if err := desc.Validate(); err != nil {
+		return Descriptor{}, errors.Wrapf(err, "invalid buildpack.toml in %s", style.Symbol(bpDir))
+	}
+	return desc, nil
+}
+
+func (d Descriptor) Validate() error {
+	if d.Buildpack.ID == "" {
+		return fmt.Errorf("%s is required", style.Symbol("buildpack.id"))
+	}
+	if d.Buildpack.Version == "" {
+		return fmt.Errorf("%s is required", style.Symbol("buildpack.version"))
+	}
+	if len(d.Stacks) == 0 {
+		return fmt.Errorf("at least one %s entry is required", style.Symbol("[[stacks]]"))
+	}
+	for i, stack := range d.Stacks {
+		if stack.ID == "" {
+			return fmt.Errorf("%s is required for stack %d", style.Symbol("stacks.id"), i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package buildpack
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/config"
+)
+
+const (
+	defaultDownloadTimeout = 5 * time.Minute
+	defaultDownloadRetries = 3
+	defaultRetryDelay      = time.Second
+)
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
+
+// ConfigureDownloads applies the download settings from the pack config
+func (f *Fetcher) ConfigureDownloads(downloads *config.Downloads) error {
+	if downloads == nil {
+		return nil
+	}
+	if downloads.Timeout != "" {
+		timeout, err := time.ParseDuration(downloads.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid download timeout %q in config", downloads.Timeout)
+		}
+		f.Client = newHTTPClient(timeout)
+	}
+	if downloads.Retries != nil {
+		if *downloads.Retries < 0 {
+			return fmt.Errorf("invalid download retries %d in config", *downloads.Retries)
+		}
+		f.Retries = *downloads.Retries
+	}
+	for _, host := range downloads.Hosts {
+		if host.Host == "" {
+			return errors.New("download hosts in config must provide a host")
+		}
+		if host.Token != "" && (host.Username != "" || host.Password != "") {
+			return fmt.Errorf("download host %q in config must provide either a token or a username and password", host.Host)
+		}
+	}
+	f.Hosts = downloads.Hosts
+	return nil
+}
+
+// download fetches uri into archiveFile unless it still has the given etag. Failed attempts are retried with an
+// increasing delay, and the archive is only replaced once the whole body has been received.
+func (f *Fetcher) download(uri, etag, archiveFile string) (modified bool, newEtag string, err error) {
+	delay := f.RetryDelay
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		modified, newEtag, retry, err = f.downloadOnce(uri, etag, archiveFile)
+		if err == nil || !retry || attempt >= f.Retries {
+			return modified, newEtag, err
+		}
+		f.Logger.Verbose("Retrying download from %q in %s: %s\n", uri, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (f *Fetcher) downloadOnce(uri, etag, archiveFile string) (modified bool, newEtag string, retry bool, err error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return false, "", false, err
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if auth := f.authorization(req.URL); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return false, "", true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		f.Logger.Verbose("Downloading from %q\n", uri)
+		if err := saveArchive(resp.Body, archiveFile); err != nil {
+			return false, "", true, err
+		}
+		return true, resp.Header.Get("Etag"), false, nil
+	case resp.StatusCode == http.StatusNotModified:
+		f.Logger.Verbose("Using cached version of %q\n", uri)
+		return false, etag, false, nil
+	}
+
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return false, "", retry, fmt.Errorf("could not download from %q, code http status %d", uri, resp.StatusCode)
+}
+
+func saveArchive(r io.Reader, archiveFile string) error {
+	file, err := ioutil.TempFile(filepath.Dir(archiveFile), "download-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), archiveFile)
+}
+
+// authorization returns the Authorization header for a download: the credentials configured for the host, or else the
+// ones in the user's netrc file
+func (f *Fetcher) authorization(u *url.URL) string {
+	for _, host := range f.Hosts {
+		if host.Host != u.Host && host.Host != u.Hostname() {
+			continue
+		}
+		if host.Token != "" {
+			return "Bearer " + host.Token
+		}
+		return basicAuth(host.Username, host.Password)
+	}
+
+	if f.NetrcPath == "" {
+		return ""
+	}
+	if login, password, ok := netrcCredentials(f.NetrcPath, u.Hostname()); ok {
+		return basicAuth(login, password)
+	}
+	return ""
+}
+
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func defaultNetrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".netrc")
+	}
+	return ""
+}
+
+type netrcEntry struct {
+	machine   string
+	isDefault bool
+	login     string
+	password  string
+}
+
+// netrcCredentials looks up the login and password for a machine in a netrc file, falling back to its default entry
+func netrcCredentials(path, machine string) (login, password string, ok bool) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	var tokens []string
+	inMacro := false
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if inMacro {
+			// macro definitions run until the next blank line
+			inMacro = len(fields) > 0
+			continue
+		}
+		for i, field := range fields {
+			if field == "macdef" {
+				inMacro = true
+				fields = fields[:i]
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+
+	var entries []netrcEntry
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				i++
+				entries = append(entries, netrcEntry{machine: tokens[i]})
+			}
+		case "default":
+			entries = append(entries, netrcEntry{isDefault: true})
+		case "login", "password":
+			if i+1 < len(tokens) && len(entries) > 0 {
+				if tokens[i] == "login" {
+					entries[len(entries)-1].login = tokens[i+1]
+				} else {
+					entries[len(entries)-1].password = tokens[i+1]
+				}
+				i++
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if !entry.isDefault && entry.machine == machine {
+			return entry.login, entry.password, true
+		}
+	}
+	for _, entry := range entries {
+		if entry.isDefault {
+			return entry.login, entry.password, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,175 @@
+package buildpack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/config"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestBuildpackDownload(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("create builder is not implemented on windows")
+	}
+	spec.Run(t, "BuildpackDownload", testBuildpackDownload, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackDownload(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cacheDir string
+		server   *ghttp.Server
+		subject  *buildpack.Fetcher
+	)
+
+	serveArchive := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Etag", `"some-etag"`)
+		http.ServeFile(w, r, filepath.Join("testdata", "buildpack.tgz"))
+	}
+
+	respondWith := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}
+	}
+
+	it.Before(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "buildpack-download")
+		h.AssertNil(t, err)
+		subject = buildpack.NewFetcher(&emptyLogger{}, cacheDir)
+		subject.RetryDelay = 0
+		subject.NetrcPath = ""
+
+		server = ghttp.NewServer()
+	})
+
+	it.After(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	when("#FetchBuildpack", func() {
+		it("retries server errors", func() {
+			server.AppendHandlers(respondWith(http.StatusServiceUnavailable), respondWith(http.StatusBadGateway), serveArchive)
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+			h.AssertEq(t, len(server.ReceivedRequests()), 3)
+		})
+
+		it("gives up after the configured number of retries", func() {
+			retries := 1
+			h.AssertNil(t, subject.ConfigureDownloads(&config.Downloads{Retries: &retries}))
+			server.AppendHandlers(respondWith(http.StatusInternalServerError), respondWith(http.StatusInternalServerError))
+
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+			h.AssertError(t, err, "code http status 500")
+			h.AssertEq(t, len(server.ReceivedRequests()), 2)
+		})
+
+		it("does not retry client errors", func() {
+			server.AppendHandlers(respondWith(http.StatusNotFound))
+
+			_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+			h.AssertError(t, err, "code http status 404")
+			h.AssertEq(t, len(server.ReceivedRequests()), 1)
+		})
+
+		it("retries a truncated body without leaving it in the cache", func() {
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "100000")
+				w.Write([]byte("partial"))
+			}, serveArchive)
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from an archive\n")
+			h.AssertEq(t, len(server.ReceivedRequests()), 2)
+
+			leftovers, err := filepath.Glob(filepath.Join(cacheDir, "dl-cache", "*-*"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(leftovers), 0)
+		})
+
+		it("keeps the previous buildpack when a new archive cannot be extracted", func() {
+			server.AppendHandlers(serveArchive, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Etag", `"other-etag"`)
+				w.Write([]byte("not a tgz"))
+			})
+			bp := buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"}
+
+			out, err := subject.FetchBuildpack(".", bp)
+			h.AssertNil(t, err)
+
+			_, err = subject.FetchBuildpack(".", bp)
+			h.AssertNotNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+		})
+
+		when("credentials are configured", func() {
+			var serverURL *url.URL
+
+			it.Before(func() {
+				var err error
+				serverURL, err = url.Parse(server.URL())
+				h.AssertNil(t, err)
+			})
+
+			it("sends a bearer token to the configured host", func() {
+				h.AssertNil(t, subject.ConfigureDownloads(&config.Downloads{
+					Hosts: []config.DownloadHost{{Host: serverURL.Host, Token: "some-token"}},
+				}))
+				server.AppendHandlers(serveArchive)
+
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+				h.AssertNil(t, err)
+				h.AssertEq(t, server.ReceivedRequests()[0].Header.Get("Authorization"), "Bearer some-token")
+			})
+
+			it("sends basic auth from the netrc file", func() {
+				netrc := filepath.Join(cacheDir, "netrc")
+				h.AssertNil(t, ioutil.WriteFile(netrc, []byte(
+					"machine other.example.com login other password other\n"+
+						"machine "+serverURL.Hostname()+"\n  login some-user\n  password some-password\n",
+				), 0600))
+				subject.NetrcPath = netrc
+				server.AppendHandlers(serveArchive)
+
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: server.URL() + "/buildpack.tgz"})
+				h.AssertNil(t, err)
+				username, password, ok := server.ReceivedRequests()[0].BasicAuth()
+				h.AssertEq(t, ok, true)
+				h.AssertEq(t, username, "some-user")
+				h.AssertEq(t, password, "some-password")
+			})
+		})
+	})
+
+	when("#ConfigureDownloads", func() {
+		it("rejects an invalid timeout", func() {
+			err := subject.ConfigureDownloads(&config.Downloads{Timeout: "soon"})
+			h.AssertError(t, err, `invalid download timeout "soon"`)
+		})
+
+		it("rejects a host with both a token and a password", func() {
+			err := subject.ConfigureDownloads(&config.Downloads{
+				Hosts: []config.DownloadHost{{Host: "example.com", Token: "some-token", Password: "some-password"}},
+			})
+			h.AssertError(t, err, `download host "example.com" in config must provide either a token or a username and password`)
+		})
+	})
+}
@@ -4,13 +4,15 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/config"
 	"github.com/pkg/errors"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 type Logger interface {
@@ -18,14 +20,24 @@ type Logger interface {
 }
 
 type Fetcher struct {
-	Logger   Logger
-	CacheDir string
+	Logger       Logger
+	CacheDir     string
+	ImageFetcher ImageFetcher
+	Client       *http.Client
+	Retries      int
+	RetryDelay   time.Duration
+	Hosts        []config.DownloadHost
+	NetrcPath    string
 }
 
 func NewFetcher(logger Logger, cacheDir string) *Fetcher {
 	return &Fetcher{
-		Logger:   logger,
-		CacheDir: filepath.Join(cacheDir, "dl-cache"),
+		Logger:     logger,
+		CacheDir:   filepath.Join(cacheDir, "dl-cache"),
+		Client:     newHTTPClient(defaultDownloadTimeout),
+		Retries:    defaultDownloadRetries,
+		RetryDelay: defaultRetryDelay,
+		NetrcPath:  defaultNetrcPath(),
 	}
 }
 
@@ -35,26 +47,41 @@ func (f *Fetcher) FetchBuildpack(localSearchPath string, bp Buildpack) (out Buil
 		URI:     bp.URI,
 		Latest:  bp.Latest,
 		Version: bp.Version,
+		SHA256:  bp.SHA256,
 	}
 
-	bpURL, err := url.Parse(bp.URI)
+	if IsImageURI(bp.URI) {
+		if bp.SHA256 != "" {
+			return out, fmt.Errorf("sha256 checksums are not supported for buildpack images, reference the image by digest instead: %q", bp.URI)
+		}
+		out.Dir, err = f.handleImage(strings.TrimPrefix(bp.URI, imageScheme))
+		return out, err
+	}
+
+	uri, sha, err := splitChecksum(bp.URI, bp.SHA256)
+	if err != nil {
+		return out, err
+	}
+	out.URI, out.SHA256 = uri, sha
+
+	bpURL, err := url.Parse(uri)
 	if err != nil {
 		return out, err
 	}
 
 	switch bpURL.Scheme {
 	case "", "file":
-		out.Dir, err = f.handleFile(localSearchPath, bpURL)
+		out.Dir, err = f.handleFile(localSearchPath, bpURL, sha)
 	case "http", "https":
-		out.Dir, err = f.handleHTTP(bp)
+		out.Dir, err = f.handleHTTP(uri, sha)
 	default:
-		return out, fmt.Errorf("unsupported protocol in URI %q", bp.URI)
+		return out, fmt.Errorf("unsupported protocol in URI %q", uri)
 	}
 
 	return out, err
 }
 
-func (f *Fetcher) handleFile(localSearchPath string, bpURL *url.URL) (string, error) {
+func (f *Fetcher) handleFile(localSearchPath string, bpURL *url.URL, sha string) (string, error) {
 	path := bpURL.Path
 
 	if !bpURL.IsAbs() && !filepath.IsAbs(path) {
@@ -62,9 +89,18 @@ func (f *Fetcher) handleFile(localSearchPath string, bpURL *url.URL) (string, er
 	}
 
 	if filepath.Ext(path) != ".tgz" {
+		if sha != "" {
+			return "", fmt.Errorf("sha256 checksums can only be verified for .tgz archives: %q", path)
+		}
 		return path, nil
 	}
 
+	if sha != "" {
+		if err := verifyFileChecksum(path, sha); err != nil {
+			return "", errors.Wrapf(err, "failed to verify %q", path)
+		}
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
 		return "", errors.Wrapf(err, "could not open file to untar: %q", path)
@@ -83,13 +119,16 @@ func (f *Fetcher) handleFile(localSearchPath string, bpURL *url.URL) (string, er
 	return tmpDir, nil
 }
 
-func (f *Fetcher) handleHTTP(bp Buildpack) (string, error) {
-	bpCache := filepath.Join(f.CacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(bp.URI))))
-	if err := os.MkdirAll(bpCache, 0744); err != nil {
+// handleHTTP downloads and extracts a buildpack archive into the cache. The archive is kept next to the extracted
+// buildpack so that, when a checksum is expected, a cached download can be verified again before it is reused.
+func (f *Fetcher) handleHTTP(uri, sha string) (string, error) {
+	bpCache := filepath.Join(f.CacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(uri))))
+	if err := os.MkdirAll(f.CacheDir, 0744); err != nil {
 		return "", err
 	}
 
 	etagFile := bpCache + ".etag"
+	archiveFile := bpCache + ".tgz"
 	etagExists, err := fileExists(etagFile)
 	if err != nil {
 		return "", err
@@ -104,15 +143,42 @@ func (f *Fetcher) handleHTTP(bp Buildpack) (string, error) {
 		etag = string(bytes)
 	}
 
-	reader, etag, err := f.downloadAsStream(bp.URI, etag)
+	if etag != "" {
+		if exists, err := fileExists(bpCache); err != nil {
+			return "", err
+		} else if !exists {
+			etag = ""
+		}
+	}
+	if etag != "" && sha != "" {
+		if err := verifyFileChecksum(archiveFile, sha); err != nil {
+			f.Logger.Verbose("Discarding cached version of %q: %s\n", uri, err)
+			etag = ""
+		}
+	}
+
+	modified, etag, err := f.download(uri, etag, archiveFile)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to download from %q", bp.URI)
-	} else if reader == nil {
-		return bpCache, nil
+		return "", errors.Wrapf(err, "failed to download from %q", uri)
+	} else if !modified {
+		if sha == "" {
+			return bpCache, nil
+		}
+		// The extracted copy is not covered by the checksum, so it is replaced from the verified archive
+		return bpCache, f.extractArchive(archiveFile, bpCache)
+	}
+
+	if err := os.Remove(etagFile); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if sha != "" {
+		if err := verifyFileChecksum(archiveFile, sha); err != nil {
+			os.Remove(archiveFile)
+			return "", errors.Wrapf(err, "failed to verify download from %q", uri)
+		}
 	}
-	defer reader.Close()
 
-	if err = archive.ExtractTarGZ(reader, bpCache); err != nil {
+	if err = f.extractArchive(archiveFile, bpCache); err != nil {
 		return "", err
 	}
 
@@ -123,32 +189,31 @@ func (f *Fetcher) handleHTTP(bp Buildpack) (string, error) {
 	return bpCache, nil
 }
 
-func (f *Fetcher) downloadAsStream(uri string, etag string) (io.ReadCloser, string, error) {
-	req, err := http.NewRequest("GET", uri, nil)
+// extractArchive replaces dest with the contents of a .tgz archive. The archive is extracted next to dest and then
+// moved into place, so an interrupted extraction never leaves a partial buildpack behind.
+func (f *Fetcher) extractArchive(archiveFile, dest string) error {
+	file, err := os.Open(archiveFile)
 	if err != nil {
-		return nil, "", err
-	}
-
-	if etag != "" {
-		req.Header.Set("If-None-Match", etag)
+		return err
 	}
+	defer file.Close()
 
-	resp, err := (&http.Client{}).Do(req)
+	tmpDir, err := ioutil.TempDir(f.CacheDir, "extract-")
 	if err != nil {
-		return nil, "", err
+		return err
 	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		f.Logger.Verbose("Downloading from %q\n", uri)
-		return resp.Body, resp.Header.Get("Etag"), nil
+	defer os.RemoveAll(tmpDir)
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return err
 	}
 
-	if resp.StatusCode == 304 {
-		f.Logger.Verbose("Using cached version of %q\n", uri)
-		return nil, etag, nil
+	if err := archive.ExtractTarGZ(file, tmpDir); err != nil {
+		return err
 	}
-
-	return nil, "", fmt.Errorf("could not download from %q, code http status %d", uri, resp.StatusCode)
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dest)
 }
 
 func fileExists(file string) (bool, error) {
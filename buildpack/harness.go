@@ -0,0 +1,239 @@
+package buildpack
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/lifecycle"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	DetectPass = "pass"
+	DetectFail = "fail"
+)
+
+// Expectations describe the outcome of running a buildpack against a fixture app
+type Expectations struct {
+	// Detect is either "pass" (the default) or "fail"
+	Detect    string              `toml:"detect"`
+	Plan      []string            `toml:"plan"`
+	Layers    []LayerExpectations `toml:"layers"`
+	Processes []string            `toml:"processes"`
+}
+
+// LayerExpectations describe a layer the buildpack creates. Flags that are not set are not checked.
+type LayerExpectations struct {
+	Name   string `toml:"name"`
+	Build  *bool  `toml:"build"`
+	Launch *bool  `toml:"launch"`
+	Cache  *bool  `toml:"cache"`
+}
+
+type TestResult struct {
+	Buildpack    string        `json:"buildpack"`
+	Fixture      string        `json:"fixture"`
+	Passed       bool          `json:"passed"`
+	Failures     []string      `json:"failures"`
+	Detected     bool          `json:"detected"`
+	Plan         []string      `json:"plan"`
+	Layers       []LayerResult `json:"layers"`
+	Processes    []string      `json:"processes"`
+	BuildError   string        `json:"buildError,omitempty"`
+	DetectOutput string        `json:"detectOutput"`
+	BuildOutput  string        `json:"buildOutput"`
+}
+
+type LayerResult struct {
+	Name   string `json:"name"`
+	Build  bool   `json:"build"`
+	Launch bool   `json:"launch"`
+	Cache  bool   `json:"cache"`
+}
+
+func ReadExpectations(path string) (Expectations, error) {
+	var exp Expectations
+	if _, err := toml.DecodeFile(path, &exp); err != nil {
+		return Expectations{}, errors.Wrapf(err, "reading expectations from %s", style.Symbol(path))
+	}
+	if err := exp.Validate(); err != nil {
+		return Expectations{}, errors.Wrapf(err, "invalid expectations in %s", style.Symbol(path))
+	}
+	return exp, nil
+}
+
+func (e *Expectations) Validate() error {
+	switch e.Detect {
+	case "":
+		e.Detect = DetectPass
+	case DetectPass:
+	case DetectFail:
+		if len(e.Plan) != 0 || len(e.Layers) != 0 || len(e.Processes) != 0 {
+			return fmt.Errorf("plan, layers and processes cannot be expected when %s", style.Symbol("detect = \"fail\""))
+		}
+	default:
+		return fmt.Errorf("detect must be %s or %s, got %s", style.Symbol(DetectPass), style.Symbol(DetectFail), style.Symbol(e.Detect))
+	}
+	for _, layer := range e.Layers {
+		if layer.Name == "" {
+			return errors.New("layers must provide a name")
+		}
+	}
+	return nil
+}
+
+// Check records in result every way in which it does not meet the expectations
+func (e Expectations) Check(result *TestResult) {
+	result.Failures = nil
+	switch {
+	case e.Detect == DetectFail && result.Detected:
+		result.Failures = append(result.Failures, "expected detection to fail, but it passed")
+	case e.Detect != DetectFail && !result.Detected:
+		result.Failures = append(result.Failures, "expected detection to pass, but it failed")
+	case result.BuildError != "":
+		result.Failures = append(result.Failures, "build failed: "+result.BuildError)
+	}
+
+	if result.Detected {
+		for _, entry := range e.Plan {
+			if !contains(result.Plan, entry) {
+				result.Failures = append(result.Failures, fmt.Sprintf("expected plan entry %q", entry))
+			}
+		}
+	}
+
+	if result.Detected && result.BuildError == "" {
+		layers := map[string]LayerResult{}
+		for _, layer := range result.Layers {
+			layers[layer.Name] = layer
+		}
+		for _, expected := range e.Layers {
+			actual, ok := layers[expected.Name]
+			if !ok {
+				result.Failures = append(result.Failures, fmt.Sprintf("expected layer %q", expected.Name))
+				continue
+			}
+			for _, flag := range []struct {
+				name     string
+				expected *bool
+				actual   bool
+			}{
+				{"build", expected.Build, actual.Build},
+				{"launch", expected.Launch, actual.Launch},
+				{"cache", expected.Cache, actual.Cache},
+			} {
+				if flag.expected != nil && *flag.expected != flag.actual {
+					result.Failures = append(result.Failures, fmt.Sprintf("expected layer %q to have %s = %t", expected.Name, flag.name, *flag.expected))
+				}
+			}
+		}
+
+		for _, process := range e.Processes {
+			if !contains(result.Processes, process) {
+				result.Failures = append(result.Failures, fmt.Sprintf("expected process type %q", process))
+			}
+		}
+	}
+
+	result.Passed = len(result.Failures) == 0
+}
+
+// RecordDetect records the outcome of the detect phase. Only a detector that exits with a non-zero status code did not
+// detect; any other error is returned, as the test could not run.
+func (r *TestResult) RecordDetect(err error) error {
+	r.Detected = err == nil
+	if err != nil && !isExitError(err) {
+		return errors.Wrap(err, "running detect phase")
+	}
+	return nil
+}
+
+// RecordBuild records the outcome of the build phase. Only a builder that exits with a non-zero status code failed the
+// build; any other error is returned, as the test could not run.
+func (r *TestResult) RecordBuild(err error) error {
+	r.BuildError = ""
+	if err == nil {
+		return nil
+	}
+	if !isExitError(err) {
+		return errors.Wrap(err, "running build phase")
+	}
+	r.BuildError = err.Error()
+	return nil
+}
+
+func isExitError(err error) bool {
+	_, ok := errors.Cause(err).(interface{ ExitCode() int })
+	return ok
+}
+
+// ReadLayers fills in the plan, and the layers and processes of the buildpack with the given escaped ID, from a tar of
+// the lifecycle's layers directory with entries under "layers/"
+func (r *TestResult) ReadLayers(layersTar io.Reader, escapedID string) error {
+	r.Layers = nil
+	tr := tar.NewReader(layersTar)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading layers")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean(header.Name), "layers/")
+		switch {
+		case name == "plan.toml":
+			var plan lifecycle.Plan
+			if _, err := toml.DecodeReader(tr, &plan); err != nil {
+				return errors.Wrap(err, "reading plan.toml")
+			}
+			r.Plan = nil
+			for entry := range plan {
+				r.Plan = append(r.Plan, entry)
+			}
+			sort.Strings(r.Plan)
+		case name == path.Join(escapedID, "launch.toml"):
+			var launch lifecycle.LaunchTOML
+			if _, err := toml.DecodeReader(tr, &launch); err != nil {
+				return errors.Wrap(err, "reading launch.toml")
+			}
+			r.Processes = nil
+			for _, process := range launch.Processes {
+				r.Processes = append(r.Processes, process.Type)
+			}
+		case path.Dir(name) == escapedID && path.Ext(name) == ".toml" && path.Base(name) != "store.toml":
+			var metadata lifecycle.LayerMetadata
+			if _, err := toml.DecodeReader(tr, &metadata); err != nil {
+				return errors.Wrapf(err, "reading %s", path.Base(name))
+			}
+			r.Layers = append(r.Layers, LayerResult{
+				Name:   strings.TrimSuffix(path.Base(name), ".toml"),
+				Build:  metadata.Build,
+				Launch: metadata.Launch,
+				Cache:  metadata.Cache,
+			})
+		}
+	}
+	sort.Slice(r.Layers, func(i, j int) bool { return r.Layers[i].Name < r.Layers[j].Name })
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package buildpack_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/docker"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestHarness(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Harness", testHarness, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testHarness(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	writeExpectations := func(contents string) string {
+		path := filepath.Join(tmpDir, "expectations.toml")
+		h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+
+	layersTar := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: "layers/", Typeflag: tar.TypeDir, Mode: 0755}))
+		for name, contents := range files {
+			h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents))}))
+			_, err := tw.Write([]byte(contents))
+			h.AssertNil(t, err)
+		}
+		h.AssertNil(t, tw.Close())
+		return &buf
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "buildpack-harness")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#ReadExpectations", func() {
+		it("expects detection to pass by default", func() {
+			exp, err := buildpack.ReadExpectations(writeExpectations("plan = [\"node\"]\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, exp.Detect, buildpack.DetectPass)
+			h.AssertEq(t, exp.Plan, []string{"node"})
+		})
+
+		it("rejects an unknown detect outcome", func() {
+			_, err := buildpack.ReadExpectations(writeExpectations("detect = \"maybe\"\n"))
+			h.AssertError(t, err, "detect must be 'pass' or 'fail', got 'maybe'")
+		})
+
+		it("rejects build expectations when detection should fail", func() {
+			_, err := buildpack.ReadExpectations(writeExpectations("detect = \"fail\"\nprocesses = [\"web\"]\n"))
+			h.AssertError(t, err, "plan, layers and processes cannot be expected")
+		})
+	})
+
+	when("#ReadLayers", func() {
+		it("reads the plan, and the layers and processes of the buildpack", func() {
+			var result buildpack.TestResult
+			err := result.ReadLayers(layersTar(map[string]string{
+				"layers/plan.toml":                   "[node]\nversion = \"10\"\n\n[npm]\n",
+				"layers/group.toml":                  "[[buildpacks]]\nid = \"example/node\"\n",
+				"layers/example_node/modules.toml":   "launch = true\ncache = true\n",
+				"layers/example_node/toolchain.toml": "build = true\n[metadata]\nversion = \"10\"\n",
+				"layers/example_node/launch.toml":    "processes = [{ type = \"web\", command = \"npm start\" }]\n",
+				"layers/other_bp/other.toml":         "launch = true\n",
+			}), "example_node")
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.Plan, []string{"node", "npm"})
+			h.AssertEq(t, result.Layers, []buildpack.LayerResult{
+				{Name: "modules", Launch: true, Cache: true},
+				{Name: "toolchain", Build: true},
+			})
+			h.AssertEq(t, result.Processes, []string{"web"})
+		})
+	})
+
+	when("#RecordDetect", func() {
+		it("records detection when the phase succeeds", func() {
+			var result buildpack.TestResult
+			h.AssertNil(t, result.RecordDetect(nil))
+			h.AssertEq(t, result.Detected, true)
+		})
+
+		it("records no detection when the detector exits with a non-zero status code", func() {
+			var result buildpack.TestResult
+			h.AssertNil(t, result.RecordDetect(errors.Wrap(&docker.ExitError{StatusCode: 100}, "some phase")))
+			h.AssertEq(t, result.Detected, false)
+		})
+
+		it("returns an error when the phase cannot run", func() {
+			var result buildpack.TestResult
+			err := result.RecordDetect(errors.New("failed to create 'detector' container: no such image"))
+			h.AssertError(t, err, "running detect phase: failed to create 'detector' container: no such image")
+		})
+	})
+
+	when("#RecordBuild", func() {
+		it("records the error when the builder exits with a non-zero status code", func() {
+			var result buildpack.TestResult
+			h.AssertNil(t, result.RecordBuild(&docker.ExitError{StatusCode: 1}))
+			h.AssertEq(t, result.BuildError, "failed with status code: 1")
+		})
+
+		it("returns an error when the phase cannot run", func() {
+			var result buildpack.TestResult
+			err := result.RecordBuild(errors.New("failed to copy build inputs to 'builder' container"))
+			h.AssertError(t, err, "running build phase: failed to copy build inputs")
+			h.AssertEq(t, result.BuildError, "")
+		})
+	})
+
+	when("#Check", func() {
+		var result *buildpack.TestResult
+
+		it.Before(func() {
+			result = &buildpack.TestResult{
+				Detected:  true,
+				Plan:      []string{"node"},
+				Layers:    []buildpack.LayerResult{{Name: "modules", Launch: true}},
+				Processes: []string{"web"},
+			}
+		})
+
+		it("passes when every expectation is met", func() {
+			exp, err := buildpack.ReadExpectations(writeExpectations(`
+plan = ["node"]
+processes = ["web"]
+
+[[layers]]
+name = "modules"
+launch = true
+`))
+			h.AssertNil(t, err)
+
+			exp.Check(result)
+			h.AssertEq(t, result.Passed, true)
+			h.AssertEq(t, len(result.Failures), 0)
+		})
+
+		it("lists every expectation that is not met", func() {
+			exp, err := buildpack.ReadExpectations(writeExpectations(`
+plan = ["node", "npm"]
+processes = ["worker"]
+
+[[layers]]
+name = "modules"
+launch = true
+cache = true
+
+[[layers]]
+name = "toolchain"
+`))
+			h.AssertNil(t, err)
+
+			exp.Check(result)
+			h.AssertEq(t, result.Passed, false)
+			h.AssertEq(t, result.Failures, []string{
+				`expected plan entry "npm"`,
+				`expected layer "modules" to have cache = true`,
+				`expected layer "toolchain"`,
+				`expected process type "worker"`,
+			})
+		})
+
+		it("fails when detection does not have the expected outcome", func() {
+			exp, err := buildpack.ReadExpectations(writeExpectations("detect = \"fail\"\n"))
+			h.AssertNil(t, err)
+
+			exp.Check(result)
+			h.AssertEq(t, result.Failures, []string{"expected detection to fail, but it passed"})
+		})
+
+		it("reports a failed build", func() {
+			result.BuildError = "failed with status code: 1"
+			exp, err := buildpack.ReadExpectations(writeExpectations("processes = [\"web\"]\n"))
+			h.AssertNil(t, err)
+
+			exp.Check(result)
+			h.AssertEq(t, result.Failures, []string{"build failed: failed with status code: 1"})
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package buildpack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	// ImageMetadataLabel holds the ImageMetadata of a buildpack image
+	ImageMetadataLabel = "io.buildpacks.buildpack.metadata"
+
+	imageScheme = "docker://"
+)
+
+// ImageMetadata describes the buildpack contained in a buildpack image. The listed layers, applied in order, place the
+// buildpack at /buildpacks/<escaped id>/<version>.
+type ImageMetadata struct {
+	ID      string   `json:"id"`
+	Version string   `json:"version"`
+	Layers  []string `json:"layers"`
+}
+
+type ImageFetcher interface {
+	FetchUpdatedLocalImage(ctx context.Context, name string, stdout io.Writer) (image.Image, error)
+}
+
+// IsImageURI reports whether uri refers to a buildpack image rather than a file or URL
+func IsImageURI(uri string) bool {
+	return strings.HasPrefix(uri, imageScheme)
+}
+
+// PackageImage adds the buildpack in bpDir to img as a single layer and labels it with the buildpack metadata. The
+// layer tar is written to tmpDir. Saving the image is left to the caller.
+func PackageImage(img image.Image, bpDir, tmpDir string) (ImageMetadata, error) {
+	desc, err := ReadDescriptor(bpDir)
+	if err != nil {
+		return ImageMetadata{}, err
+	}
+
+	bp := Buildpack{ID: desc.Buildpack.ID, Version: desc.Buildpack.Version}
+	layerTar := filepath.Join(tmpDir, fmt.Sprintf("%s.%s.tar", bp.EscapedID(), bp.Version))
+	if err := archive.CreateTar(layerTar, bpDir, imageBuildpackDir(bp), 0, 0); err != nil {
+		return ImageMetadata{}, errors.Wrap(err, "creating buildpack layer")
+	}
+	diffID, err := fileDigest(layerTar)
+	if err != nil {
+		return ImageMetadata{}, err
+	}
+	if err := img.AddLayer(layerTar); err != nil {
+		return ImageMetadata{}, errors.Wrap(err, "adding buildpack layer")
+	}
+
+	metadata := ImageMetadata{ID: bp.ID, Version: bp.Version, Layers: []string{diffID}}
+	label, err := json.Marshal(metadata)
+	if err != nil {
+		return ImageMetadata{}, err
+	}
+	if err := img.SetLabel(ImageMetadataLabel, string(label)); err != nil {
+		return ImageMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// handleImage pulls a buildpack image and extracts its buildpack layers. Extracted buildpacks are cached by layer, so
+// an image is only extracted again when its buildpack changes.
+func (f *Fetcher) handleImage(imageName string) (string, error) {
+	if f.ImageFetcher == nil {
+		return "", fmt.Errorf("cannot fetch buildpack image %s", style.Symbol(imageName))
+	}
+
+	f.Logger.Verbose("Pulling buildpack image %q\n", imageName)
+	img, err := f.ImageFetcher.FetchUpdatedLocalImage(context.Background(), imageName, ioutil.Discard)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch buildpack image %s", style.Symbol(imageName))
+	}
+	if found, err := img.Found(); err != nil {
+		return "", err
+	} else if !found {
+		return "", fmt.Errorf("buildpack image %s does not exist", style.Symbol(imageName))
+	}
+
+	label, err := img.Label(ImageMetadataLabel)
+	if err != nil {
+		return "", err
+	}
+	if label == "" {
+		return "", fmt.Errorf("image %s is not a buildpack image: missing label %s", style.Symbol(imageName), style.Symbol(ImageMetadataLabel))
+	}
+	var metadata ImageMetadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return "", errors.Wrapf(err, "failed to parse label %s of image %s", style.Symbol(ImageMetadataLabel), style.Symbol(imageName))
+	}
+	if metadata.ID == "" || metadata.Version == "" || len(metadata.Layers) == 0 {
+		return "", fmt.Errorf("label %s of image %s must provide an id, a version and layers", style.Symbol(ImageMetadataLabel), style.Symbol(imageName))
+	}
+
+	bpCache := filepath.Join(f.CacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(metadata.Layers, ",")))))
+	bp := Buildpack{ID: metadata.ID, Version: metadata.Version}
+	bpDir := filepath.Join(bpCache, filepath.FromSlash(imageBuildpackDir(bp)))
+	if exists, err := fileExists(bpDir); err != nil {
+		return "", err
+	} else if exists {
+		f.Logger.Verbose("Using cached version of %q\n", imageName)
+		return bpDir, nil
+	}
+
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		return "", err
+	}
+	tmpDir, err := ioutil.TempDir(f.CacheDir, "image-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, diffID := range metadata.Layers {
+		if err := extractLayer(img, diffID, tmpDir); err != nil {
+			return "", errors.Wrapf(err, "failed to extract layer %s of image %s", style.Symbol(diffID), style.Symbol(imageName))
+		}
+	}
+	if exists, err := fileExists(filepath.Join(tmpDir, filepath.FromSlash(imageBuildpackDir(bp)), "buildpack.toml")); err != nil {
+		return "", err
+	} else if !exists {
+		return "", fmt.Errorf("image %s does not contain buildpack %s", style.Symbol(imageName), style.Symbol(bp.ID+"@"+bp.Version))
+	}
+
+	if err := os.RemoveAll(bpCache); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, bpCache); err != nil {
+		return "", err
+	}
+	return bpDir, nil
+}
+
+func extractLayer(img image.Image, diffID, dest string) error {
+	layer, err := img.GetLayer(diffID)
+	if err != nil {
+		return err
+	}
+	defer layer.Close()
+	return archive.ExtractTar(layer, dest)
+}
+
+func imageBuildpackDir(bp Buildpack) string {
+	return "/buildpacks/" + bp.EscapedID() + "/" + bp.Version
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
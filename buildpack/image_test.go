@@ -0,0 +1,139 @@
+package buildpack_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestBuildpackImage(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("buildpack images are not implemented on windows")
+	}
+	spec.Run(t, "BuildpackImage", testBuildpackImage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockFetcher    *mocks.MockFetcher
+		tmpDir         string
+		cacheDir       string
+		subject        *buildpack.Fetcher
+	)
+
+	it.Before(func() {
+		var err error
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+
+		tmpDir, err = ioutil.TempDir("", "buildpack-image")
+		h.AssertNil(t, err)
+		cacheDir, err = ioutil.TempDir("", "buildpack-image-cache")
+		h.AssertNil(t, err)
+
+		subject = buildpack.NewFetcher(&emptyLogger{}, cacheDir)
+		subject.ImageFetcher = mockFetcher
+	})
+
+	it.After(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+		os.RemoveAll(cacheDir)
+	})
+
+	when("#PackageImage", func() {
+		it("adds the buildpack as a layer and labels the image", func() {
+			img := imgtest.NewFakeImage(t, "some/buildpack-image", "", "")
+
+			metadata, err := buildpack.PackageImage(img, filepath.Join("testdata", "buildpack"), tmpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, metadata.ID, "some-buildpack-id")
+			h.AssertEq(t, metadata.Version, "some-buildpack-version")
+			h.AssertEq(t, len(metadata.Layers), 1)
+
+			label, err := img.Label(buildpack.ImageMetadataLabel)
+			h.AssertNil(t, err)
+			var labelMetadata buildpack.ImageMetadata
+			h.AssertNil(t, json.Unmarshal([]byte(label), &labelMetadata))
+			h.AssertEq(t, labelMetadata, metadata)
+		})
+
+		it("fails when buildpack.toml has no version", func() {
+			bpDir := filepath.Join(tmpDir, "buildpack")
+			h.AssertNil(t, os.MkdirAll(bpDir, 0755))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpDir, "buildpack.toml"), []byte("[buildpack]\nid = \"some-id\"\n"), 0644))
+
+			_, err := buildpack.PackageImage(imgtest.NewFakeImage(t, "some/buildpack-image", "", ""), bpDir, tmpDir)
+			h.AssertError(t, err, "'buildpack.version' is required")
+		})
+	})
+
+	when("#FetchBuildpack", func() {
+		when("the URI is a 'docker://' image", func() {
+			var img *imgtest.FakeImage
+
+			it.Before(func() {
+				img = imgtest.NewFakeImage(t, "registry.example.com/some/buildpack:1.0", "", "")
+				_, err := buildpack.PackageImage(img, filepath.Join("testdata", "buildpack"), tmpDir)
+				h.AssertNil(t, err)
+			})
+
+			it("extracts the buildpack from the image", func() {
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "registry.example.com/some/buildpack:1.0", gomock.Any()).Return(img, nil)
+
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "docker://registry.example.com/some/buildpack:1.0",
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.ID, "some-buildpack-id")
+				h.AssertContains(t, out.Dir, cacheDir)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from a directory\n")
+				h.AssertDirContainsFileWithContents(t, out.Dir, "buildpack.toml", "[buildpack]\nid = \"some-buildpack-id\"\nversion = \"some-buildpack-version\"\n\n[[stacks]]\nid = \"some.stack.id\"\n")
+			})
+
+			it("reuses the extracted buildpack while the image layers are unchanged", func() {
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "registry.example.com/some/buildpack:1.0", gomock.Any()).Return(img, nil).Times(2)
+
+				first, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: "docker://registry.example.com/some/buildpack:1.0"})
+				h.AssertNil(t, err)
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(first.Dir, "marker"), []byte("cached"), 0644))
+
+				second, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: "docker://registry.example.com/some/buildpack:1.0"})
+				h.AssertNil(t, err)
+				h.AssertEq(t, second.Dir, first.Dir)
+				h.AssertDirContainsFileWithContents(t, second.Dir, "marker", "cached")
+			})
+
+			it("fails when the image is not a buildpack image", func() {
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/other-image", gomock.Any()).
+					Return(imgtest.NewFakeImage(t, "some/other-image", "", ""), nil)
+
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: "docker://some/other-image"})
+				h.AssertError(t, err, "image 'some/other-image' is not a buildpack image")
+			})
+
+			it("fails without an image fetcher", func() {
+				subject.ImageFetcher = nil
+
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: "docker://some/buildpack"})
+				h.AssertError(t, err, "cannot fetch buildpack image 'some/buildpack'")
+			})
+		})
+	})
+}
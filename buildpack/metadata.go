@@ -6,8 +6,11 @@ type Buildpack struct {
 	ID      string `toml:"id"`
 	URI     string `toml:"uri"`
 	Latest  bool   `toml:"latest"`
+	SHA256  string `toml:"sha256"`
 	Dir     string
 	Version string
+	// Stacks are the stacks declared by the buildpack's buildpack.toml, once it has been read
+	Stacks []string `toml:"-"`
 }
 
 func (b *Buildpack) EscapedID() string {
@@ -0,0 +1,153 @@
+package buildpack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	DefaultScaffoldVersion  = "0.0.1"
+	DefaultScaffoldStack    = "io.buildpacks.stacks.bionic"
+	DefaultScaffoldTemplate = "generic"
+)
+
+// scaffoldTemplates maps the templates of create-buildpack to the file that makes their detect script pass. The
+// generic template always passes.
+var scaffoldTemplates = map[string]string{
+	"generic": "",
+	"go":      "go.mod",
+	"java":    "pom.xml",
+	"node":    "package.json",
+	"python":  "requirements.txt",
+	"ruby":    "Gemfile",
+}
+
+// ScaffoldTemplates returns the names of the templates Scaffold accepts
+func ScaffoldTemplates() []string {
+	var names []string
+	for name := range scaffoldTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type ScaffoldOptions struct {
+	ID       string
+	Version  string
+	Name     string
+	Stacks   []string
+	Template string
+}
+
+var detectTemplate = template.Must(template.New("detect").Parse(`#!/usr/bin/env bash
+set -eo pipefail
+
+# bin/detect <platform-dir> <plan-path>
+#
+# Runs in the app directory. Exit with 0 to take part in the build, or with 100 to opt out. Requirements for later
+# buildpacks may be written to the plan as TOML.
+platform_dir=$1
+plan_path=$2
+{{if .Marker}}
+if [[ ! -f {{.Marker}} ]]; then
+  exit 100
+fi
+{{end}}
+exit 0
+`))
+
+var buildTemplate = template.Must(template.New("build").Parse(`#!/usr/bin/env bash
+set -eo pipefail
+
+# bin/build <layers-dir> <platform-dir> <plan-path>
+#
+# Runs in the app directory. Each directory in the layers directory is a layer, described by a <layer>.toml file
+# next to it. Processes of the app image are declared in launch.toml.
+layers_dir=$1
+platform_dir=$2
+plan_path=$3
+
+echo "---> {{.Name}}"
+
+layer_dir="$layers_dir/{{.Layer}}"
+mkdir -p "$layer_dir"
+echo "launch = true" > "$layer_dir.toml"
+
+# echo 'processes = [{ type = "web", command = "<start command>" }]' > "$layers_dir/launch.toml"
+`))
+
+// Scaffold writes a new buildpack to dir: a buildpack.toml and executable bin/detect and bin/build scripts following
+// the lifecycle contract. dir must not exist or be empty.
+func Scaffold(dir string, opts ScaffoldOptions) (Descriptor, error) {
+	if opts.Version == "" {
+		opts.Version = DefaultScaffoldVersion
+	}
+	if len(opts.Stacks) == 0 {
+		opts.Stacks = []string{DefaultScaffoldStack}
+	}
+	if opts.Template == "" {
+		opts.Template = DefaultScaffoldTemplate
+	}
+	marker, ok := scaffoldTemplates[opts.Template]
+	if !ok {
+		return Descriptor{}, fmt.Errorf("unknown template %s, must be one of %s", style.Symbol(opts.Template), strings.Join(ScaffoldTemplates(), ", "))
+	}
+
+	desc := Descriptor{Buildpack: DescriptorInfo{ID: opts.ID, Version: opts.Version, Name: opts.Name}}
+	for _, stack := range opts.Stacks {
+		desc.Stacks = append(desc.Stacks, DescriptorStack{ID: stack})
+	}
+	if err := desc.Validate(); err != nil {
+		return Descriptor{}, err
+	}
+
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) > 0 {
+		return Descriptor{}, fmt.Errorf("directory %s is not empty", style.Symbol(dir))
+	} else if err != nil && !os.IsNotExist(err) {
+		return Descriptor{}, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		return Descriptor{}, err
+	}
+
+	var descBuf bytes.Buffer
+	if err := toml.NewEncoder(&descBuf).Encode(desc); err != nil {
+		return Descriptor{}, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "buildpack.toml"), descBuf.Bytes(), 0644); err != nil {
+		return Descriptor{}, err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = opts.ID
+	}
+	bp := Buildpack{ID: opts.ID}
+	data := struct{ Name, Layer, Marker string }{Name: name, Layer: bp.EscapedID(), Marker: marker}
+	for _, script := range []*template.Template{detectTemplate, buildTemplate} {
+		var buf bytes.Buffer
+		if err := script.Execute(&buf, data); err != nil {
+			return Descriptor{}, errors.Wrapf(err, "rendering bin/%s", script.Name())
+		}
+		path := filepath.Join(dir, "bin", script.Name())
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0755); err != nil {
+			return Descriptor{}, err
+		}
+		if err := os.Chmod(path, 0755); err != nil {
+			return Descriptor{}, err
+		}
+	}
+	return desc, nil
+}
@@ -0,0 +1,114 @@
+package buildpack_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestScaffold(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("buildpack scripts are not run on windows")
+	}
+	spec.Run(t, "Scaffold", testScaffold, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testScaffold(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir, bpDir, appDir string
+
+	runDetect := func() int {
+		cmd := exec.Command(filepath.Join(bpDir, "bin", "detect"), filepath.Join(tmpDir, "platform"), filepath.Join(tmpDir, "plan.toml"))
+		cmd.Dir = appDir
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			h.AssertEq(t, ok, true)
+			return exitErr.Sys().(syscall.WaitStatus).ExitStatus()
+		}
+		return 0
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "buildpack-scaffold")
+		h.AssertNil(t, err)
+		bpDir = filepath.Join(tmpDir, "buildpack")
+		appDir = filepath.Join(tmpDir, "app")
+		h.AssertNil(t, os.Mkdir(appDir, 0755))
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#Scaffold", func() {
+		it("writes a valid buildpack.toml with the defaults", func() {
+			_, err := buildpack.Scaffold(bpDir, buildpack.ScaffoldOptions{ID: "example/some-buildpack"})
+			h.AssertNil(t, err)
+
+			desc, err := buildpack.ReadDescriptor(bpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, desc.Buildpack.ID, "example/some-buildpack")
+			h.AssertEq(t, desc.Buildpack.Version, buildpack.DefaultScaffoldVersion)
+			h.AssertEq(t, desc.Stacks, []buildpack.DescriptorStack{{ID: buildpack.DefaultScaffoldStack}})
+		})
+
+		it("writes executable scripts that follow the lifecycle contract", func() {
+			_, err := buildpack.Scaffold(bpDir, buildpack.ScaffoldOptions{ID: "some-buildpack", Name: "Some Buildpack"})
+			h.AssertNil(t, err)
+
+			for _, script := range []string{"detect", "build"} {
+				info, err := os.Stat(filepath.Join(bpDir, "bin", script))
+				h.AssertNil(t, err)
+				h.AssertEq(t, info.Mode().Perm(), os.FileMode(0755))
+			}
+			h.AssertEq(t, runDetect(), 0)
+
+			layersDir := filepath.Join(tmpDir, "layers")
+			h.AssertNil(t, os.Mkdir(layersDir, 0755))
+			cmd := exec.Command(filepath.Join(bpDir, "bin", "build"), layersDir, filepath.Join(tmpDir, "platform"), filepath.Join(tmpDir, "plan.toml"))
+			cmd.Dir = appDir
+			out, err := cmd.CombinedOutput()
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(out), "---> Some Buildpack")
+			h.AssertDirContainsFileWithContents(t, layersDir, "some-buildpack.toml", "launch = true\n")
+		})
+
+		it("detects the marker file of a language template", func() {
+			_, err := buildpack.Scaffold(bpDir, buildpack.ScaffoldOptions{ID: "some-buildpack", Template: "node", Stacks: []string{"some.stack.id", "other.stack.id"}})
+			h.AssertNil(t, err)
+			h.AssertEq(t, runDetect(), 100)
+
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "package.json"), []byte("{}"), 0644))
+			h.AssertEq(t, runDetect(), 0)
+
+			desc, err := buildpack.ReadDescriptor(bpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(desc.Stacks), 2)
+		})
+
+		it("rejects an unknown template", func() {
+			_, err := buildpack.Scaffold(bpDir, buildpack.ScaffoldOptions{ID: "some-buildpack", Template: "cobol"})
+			h.AssertError(t, err, "unknown template 'cobol', must be one of generic, go, java, node, python, ruby")
+		})
+
+		it("does not write into a directory that is not empty", func() {
+			h.AssertNil(t, os.MkdirAll(bpDir, 0755))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpDir, "existing"), []byte("existing"), 0644))
+
+			_, err := buildpack.Scaffold(bpDir, buildpack.ScaffoldOptions{ID: "some-buildpack"})
+			h.AssertError(t, err, "is not empty")
+		})
+	})
+}
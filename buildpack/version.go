@@ -0,0 +1,244 @@
+package buildpack
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+// version is a semantic version. Build metadata is ignored.
+type version struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseVersion parses a full or partial semantic version, returning how many of major, minor and patch it provides
+func parseVersion(s string) (v version, parts int, err error) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.pre = s[i+1:]
+		s = s[:i]
+		if v.pre == "" {
+			return version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 || (v.pre != "" && len(fields) != 3) {
+		return version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, len(fields), nil
+}
+
+func (v version) compare(o version) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	}
+	return comparePrerelease(v.pre, o.pre)
+}
+
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
+
+type comparator struct {
+	op string
+	v  version
+}
+
+func (c comparator) matches(v version) bool {
+	cmp := v.compare(c.v)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+// IsVersionConstraint reports whether a group version is a constraint, such as "^1.2" or ">=2.0, <3", rather than
+// an exact version
+func IsVersionConstraint(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "*" || (s != "" && strings.ContainsRune("^~<>=", rune(s[0])))
+}
+
+// parseConstraint turns a constraint into comparators that must all match. It supports "^", "~", ">", ">=", "<",
+// "<=", "=" and "*", with several comparators separated by commas or spaces.
+func parseConstraint(constraint string) ([]comparator, error) {
+	var comparators []comparator
+	for _, term := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if term == "*" {
+			continue
+		}
+		i := strings.IndexFunc(term, func(r rune) bool { return unicode.IsDigit(r) || r == 'v' })
+		if i < 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+		op := term[:i]
+		v, parts, err := parseVersion(term[i:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint %q", constraint)
+		}
+
+		switch op {
+		case "^":
+			upper := version{major: v.major + 1}
+			switch {
+			case v.major == 0 && parts > 1 && v.minor == 0 && parts > 2:
+				upper = version{patch: v.patch + 1}
+			case v.major == 0 && parts > 1:
+				upper = version{minor: v.minor + 1}
+			}
+			comparators = append(comparators, comparator{">=", v}, comparator{"<", withPre(upper)})
+		case "~":
+			upper := version{major: v.major + 1}
+			if parts > 1 {
+				upper = version{major: v.major, minor: v.minor + 1}
+			}
+			comparators = append(comparators, comparator{">=", v}, comparator{"<", withPre(upper)})
+		case "", ">", ">=", "<", "<=", "=":
+			comparators = append(comparators, plainComparators(op, v, parts)...)
+		default:
+			return nil, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+	}
+	return comparators, nil
+}
+
+// plainComparators expands a comparator on a partial version to the range of versions it stands for, so that "1.2" is
+// any 1.2.x: "<=1.2" becomes "<1.3.0-0", ">1.2" becomes ">=1.3.0", "<1.2" becomes "<1.2.0-0" and "=1.2" becomes
+// ">=1.2.0, <1.3.0-0"
+func plainComparators(op string, v version, parts int) []comparator {
+	if parts == 3 {
+		return []comparator{{op, v}}
+	}
+	next := version{major: v.major + 1}
+	if parts == 2 {
+		next = version{major: v.major, minor: v.minor + 1}
+	}
+	switch op {
+	case ">":
+		return []comparator{{">=", next}}
+	case ">=":
+		return []comparator{{">=", v}}
+	case "<":
+		return []comparator{{"<", withPre(v)}}
+	case "<=":
+		return []comparator{{"<", withPre(next)}}
+	}
+	return []comparator{{">=", v}, {"<", withPre(next)}}
+}
+
+// withPre makes an exclusive upper bound exclude the pre-releases of that version too
+func withPre(v version) version {
+	v.pre = "0"
+	return v
+}
+
+// ResolveVersion returns the highest of versions that satisfies constraint. Pre-releases are only considered when
+// the constraint itself names a pre-release.
+func ResolveVersion(constraint string, versions []string) (string, error) {
+	comparators, err := parseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	allowPre := strings.Contains(constraint, "-")
+
+	type candidate struct {
+		raw string
+		v   version
+	}
+	var candidates []candidate
+	for _, raw := range versions {
+		v, parts, err := parseVersion(raw)
+		if err != nil || parts != 3 || (v.pre != "" && !allowPre) {
+			continue
+		}
+		matches := true
+		for _, c := range comparators {
+			if !c.matches(v) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			candidates = append(candidates, candidate{raw, v})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no version matches %s", style.Symbol(constraint))
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].v.compare(candidates[j].v) > 0 })
+	return candidates[0].raw, nil
+}
+
+// SortVersions sorts versions from lowest to highest by semantic version precedence. Versions that are not semantic
+// versions sort after the others, in lexical order.
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, _, errI := parseVersion(versions[i])
+		vj, _, errJ := parseVersion(versions[j])
+		switch {
+		case errI != nil && errJ != nil:
+			return versions[i] < versions[j]
+		case errI != nil || errJ != nil:
+			return errJ != nil
+		}
+		if c := vi.compare(vj); c != 0 {
+			return c < 0
+		}
+		return versions[i] < versions[j]
+	})
+}
@@ -0,0 +1,108 @@
+package buildpack_test
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestVersion(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Version", testVersion, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testVersion(t *testing.T, when spec.G, it spec.S) {
+	when("#IsVersionConstraint", func() {
+		it("recognizes constraints", func() {
+			for _, v := range []string{"^1.2", "~2.0", ">=1.0.0, <2", "=1.2.3", "*"} {
+				h.AssertEq(t, buildpack.IsVersionConstraint(v), true)
+			}
+		})
+
+		it("treats exact versions and latest as not constraints", func() {
+			for _, v := range []string{"1.2.3", "0.0.1-mock", "latest", ""} {
+				h.AssertEq(t, buildpack.IsVersionConstraint(v), false)
+			}
+		})
+	})
+
+	when("#SortVersions", func() {
+		it("sorts by semantic version precedence, with other versions last", func() {
+			versions := []string{"not-semver", "1.10.0", "1.2.0", "1.2.0-rc.10", "1.2.0-rc.2", "0.9", "another-version"}
+			buildpack.SortVersions(versions)
+			h.AssertEq(t, versions, []string{"0.9", "1.2.0-rc.2", "1.2.0-rc.10", "1.2.0", "1.10.0", "another-version", "not-semver"})
+		})
+	})
+
+	when("#ResolveVersion", func() {
+		versions := []string{"0.2.1", "0.2.5", "0.3.0", "1.1.0", "1.2.0", "1.2.7", "1.9.0", "2.0.0-rc.1", "2.0.1", "not-semver"}
+
+		for _, tc := range []struct {
+			constraint, expected string
+		}{
+			{"^1.2", "1.9.0"},
+			{"^1.2.3", "1.9.0"},
+			{"^0.2", "0.2.5"},
+			{"^0.2.1", "0.2.5"},
+			{"~1.2", "1.2.7"},
+			{"~1", "1.9.0"},
+			{"~2.0", "2.0.1"},
+			{">=1.0, <1.2", "1.1.0"},
+			{">1.2.0 <=1.2.7", "1.2.7"},
+			{"=1.2.0", "1.2.0"},
+			{"*", "2.0.1"},
+			{"~2.0.0-rc.0", "2.0.1"},
+			{"<2.0.1, >=2.0.0-rc.0", "2.0.0-rc.1"},
+			{"<=1.2", "1.2.7"},
+			{"<=1", "1.9.0"},
+			{">1.2", "2.0.1"},
+			{">1.2, <2", "1.9.0"},
+			{">0.2, <1", "0.3.0"},
+			{"<1.2", "1.1.0"},
+			{"<2", "1.9.0"},
+			{">=1.2, <=1.2", "1.2.7"},
+			{"=1.2", "1.2.7"},
+			{"=0", "0.3.0"},
+		} {
+			tc := tc
+			it("resolves "+tc.constraint+" to the highest matching version", func() {
+				resolved, err := buildpack.ResolveVersion(tc.constraint, versions)
+				h.AssertNil(t, err)
+				h.AssertEq(t, resolved, tc.expected)
+			})
+		}
+
+		it("skips pre-releases unless the constraint names one", func() {
+			_, err := buildpack.ResolveVersion("<2.0.1, >2.0.0-alpha", []string{"2.0.0-rc.1"})
+			h.AssertNil(t, err)
+
+			_, err = buildpack.ResolveVersion("^2", []string{"2.0.0-rc.1"})
+			h.AssertError(t, err, "no version matches '^2'")
+		})
+
+		it("excludes every version a partial version stands for from exclusive bounds", func() {
+			_, err := buildpack.ResolveVersion(">1.2", []string{"1.2.0", "1.2.7"})
+			h.AssertError(t, err, "no version matches '>1.2'")
+
+			_, err = buildpack.ResolveVersion("<1.2", []string{"1.2.0", "1.2.7"})
+			h.AssertError(t, err, "no version matches '<1.2'")
+		})
+
+		it("fails when no version matches", func() {
+			_, err := buildpack.ResolveVersion("^3", versions)
+			h.AssertError(t, err, "no version matches '^3'")
+		})
+
+		it("fails for invalid constraints", func() {
+			for _, c := range []string{"^x", "~1.2.3.4", "!1.2", ">=1.2-"} {
+				_, err := buildpack.ResolveVersion(c, versions)
+				h.AssertError(t, err, "invalid version constraint")
+			}
+		})
+	})
+}
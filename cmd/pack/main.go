@@ -45,11 +45,18 @@ func main() {
 	rootCmd.AddCommand(commands.Build(&logger, &imageFetcher))
 	rootCmd.AddCommand(commands.Run(&logger, &imageFetcher))
 	rootCmd.AddCommand(commands.Rebase(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.Outdated(&logger, &client))
 
 	rootCmd.AddCommand(commands.CreateBuilder(&logger, &imageFetcher, &buildpackFetcher))
+	rootCmd.AddCommand(commands.Builder(&logger, &imageFetcher, &buildpackFetcher))
 	rootCmd.AddCommand(commands.SetRunImagesMirrors(&logger))
 	rootCmd.AddCommand(commands.InspectBuilder(&logger, &cfg, &client))
-	rootCmd.AddCommand(commands.SetDefaultBuilder(&logger))
+	rootCmd.AddCommand(commands.DiffBuilder(&logger, &client))
+	rootCmd.AddCommand(commands.SetDefaultBuilder(&logger, &client))
+	rootCmd.AddCommand(commands.CreateBuildpack(&logger))
+	rootCmd.AddCommand(commands.TestBuildpack(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.PackageBuildpack(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.CreateStack(&logger, &imageFetcher))
 
 	rootCmd.AddCommand(commands.Version(&logger, Version))
 
@@ -87,7 +94,12 @@ func initImageFetcher(logger logging.Logger) pack.ImageFetcher {
 }
 
 func initBuildpackFetcher(logger logging.Logger) buildpack.Fetcher {
-	return *buildpack.NewFetcher(&logger, cfg.Path())
+	fetcher := buildpack.NewFetcher(&logger, cfg.Path())
+	fetcher.ImageFetcher = &imageFetcher
+	if err := fetcher.ConfigureDownloads(cfg.Downloads); err != nil {
+		exitError(logger, err)
+	}
+	return *fetcher
 }
 
 func exitError(logger logging.Logger, err error) {
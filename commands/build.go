@@ -85,19 +85,28 @@ func Build(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 func suggestSettingBuilder(logger *logging.Logger) {
 	logger.Info("Please select a default builder with:\n")
 	logger.Info("\tpack set-default-builder <builder image>\n")
-	suggestBuilders(logger)
+	suggestBuilders(logger, nil)
 }
 
-func suggestBuilders(logger *logging.Logger) {
+// suggestBuilders lists the suggested builders. When inspector is given, a builder that is available locally is described
+// by the description it declares.
+func suggestBuilders(logger *logging.Logger, inspector BuilderInspector) {
 	logger.Info("Suggested builders:\n")
 	tw := tabwriter.NewWriter(logger.RawWriter(), 10, 10, 5, ' ', tabwriter.TabIndent)
-	for len(suggestedBuilders) > 0 {
-		n := rand.Intn(len(suggestedBuilders))
-		builders := suggestedBuilders[n]
+	remaining := append([][]suggestedBuilder{}, suggestedBuilders...)
+	for len(remaining) > 0 {
+		n := rand.Intn(len(remaining))
+		builders := remaining[n]
 		for _, builder := range builders {
-			tw.Write([]byte(fmt.Sprintf("\t%s:\t%s\t%s\t\n", builder.name, style.Symbol(builder.image), builder.info)))
+			info := builder.info
+			if inspector != nil {
+				if local, err := inspector.InspectBuilder(builder.image, true); err == nil && local != nil && local.Description != "" {
+					info = local.Description
+				}
+			}
+			tw.Write([]byte(fmt.Sprintf("\t%s:\t%s\t%s\t\n", builder.name, style.Symbol(builder.image), info)))
 		}
-		suggestedBuilders = append(suggestedBuilders[:n], suggestedBuilders[n+1:]...)
+		remaining = append(remaining[:n], remaining[n+1:]...)
 	}
 	tw.Flush()
 	logger.Info("")
@@ -113,5 +122,9 @@ func buildCommandFlags(cmd *cobra.Command, buildFlags *pack.BuildFlags) {
 	cmd.Flags().StringVar(&buildFlags.EnvFile, "env-file", "", "Build-time environment variables file\nOne variable per line, of the form 'VAR=VALUE' or 'VAR'\nWhen using latter value-less form, value will be taken from current\n  environment at the time this command is executed")
 	cmd.Flags().BoolVar(&buildFlags.NoPull, "no-pull", false, "Skip pulling builder and run images before use")
 	cmd.Flags().BoolVar(&buildFlags.ClearCache, "clear-cache", false, "Clear image's associated cache before building")
-	cmd.Flags().StringSliceVar(&buildFlags.Buildpacks, "buildpack", nil, "Buildpack ID or path to a buildpack directory"+multiValueHelp("buildpack"))
+	cmd.Flags().StringSliceVar(&buildFlags.Buildpacks, "buildpack", nil, "Buildpack ID, path to a buildpack directory or .tgz, URL of a buildpack .tgz (optionally with #sha256=<checksum>), or docker:// buildpack image"+multiValueHelp("buildpack"))
+	cmd.Flags().StringVar(&buildFlags.Order, "order", "", "Path to an order.toml defining the buildpack groups to use instead of the builder's")
+	cmd.Flags().BoolVar(&buildFlags.SingleContainer, "single-container", false, "Run all lifecycle phases in a single container, which is faster on slow Docker daemons")
+	cmd.Flags().BoolVar(&buildFlags.ReuseAppVolume, "reuse-app-volume", false, "Keep the app in a volume between builds and upload only changed files")
+	cmd.Flags().StringVar(&buildFlags.LifecycleImage, "lifecycle-image", "", "Image providing the lifecycle binaries in '/lifecycle'\n  (defaults to the lifecycle image declared by the builder, if any, otherwise the builder's own lifecycle)")
 }
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func Builder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "builder",
+		Short: "Modify an existing builder image",
+	}
+	cmd.AddCommand(builderAddBuildpack(logger, fetcher, bpFetcher))
+	cmd.AddCommand(builderRemoveBuildpack(logger, fetcher, bpFetcher))
+	cmd.AddCommand(builderSetOrder(logger, fetcher, bpFetcher))
+	AddHelpFlag(cmd, "builder")
+	return cmd
+}
+
+func builderAddBuildpack(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+	var flags pack.AddBuildpackFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "add-buildpack <builder-image-name> <buildpack-uri>",
+		Args:  cobra.ExactArgs(2),
+		Short: "Add a buildpack to a builder image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.BuilderName, flags.BuildpackURI = args[0], args[1]
+			factory, err := modifyBuilderFactory(logger, fetcher, bpFetcher)
+			if err != nil {
+				return err
+			}
+			imageName, err := factory.AddBuildpack(ctx, flags)
+			if err != nil {
+				return err
+			}
+			logger.Info("Successfully saved builder image %s", style.Symbol(imageName))
+			logger.Tip("Run %s to use the buildpack", style.Symbol("pack builder set-order"))
+			return nil
+		}),
+	}
+	addModifyBuilderFlags(cmd, &flags.ModifyBuilderFlags)
+	cmd.Flags().BoolVar(&flags.Latest, "latest", false, "Mark the buildpack as the latest version of its ID")
+	AddHelpFlag(cmd, "add-buildpack")
+	return cmd
+}
+
+func builderRemoveBuildpack(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+	var flags pack.RemoveBuildpackFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "remove-buildpack <builder-image-name> <buildpack-id>[@<version>]",
+		Args:  cobra.ExactArgs(2),
+		Short: "Remove a buildpack from a builder image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.BuilderName, flags.Buildpack = args[0], args[1]
+			factory, err := modifyBuilderFactory(logger, fetcher, bpFetcher)
+			if err != nil {
+				return err
+			}
+			imageName, err := factory.RemoveBuildpack(ctx, flags)
+			if err != nil {
+				return err
+			}
+			logger.Info("Successfully saved builder image %s", style.Symbol(imageName))
+			return nil
+		}),
+	}
+	addModifyBuilderFlags(cmd, &flags.ModifyBuilderFlags)
+	AddHelpFlag(cmd, "remove-buildpack")
+	return cmd
+}
+
+func builderSetOrder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+	var flags pack.SetOrderFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "set-order <builder-image-name> --order <order-path>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Replace the buildpack groups of a builder image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.BuilderName = args[0]
+			factory, err := modifyBuilderFactory(logger, fetcher, bpFetcher)
+			if err != nil {
+				return err
+			}
+			imageName, err := factory.SetOrder(ctx, flags)
+			if err != nil {
+				return err
+			}
+			logger.Info("Successfully saved builder image %s", style.Symbol(imageName))
+			return nil
+		}),
+	}
+	addModifyBuilderFlags(cmd, &flags.ModifyBuilderFlags)
+	cmd.Flags().StringVarP(&flags.OrderPath, "order", "o", "", "Path to an order.toml file with the new [[groups]] (required)")
+	cmd.MarkFlagRequired("order")
+	AddHelpFlag(cmd, "set-order")
+	return cmd
+}
+
+func addModifyBuilderFlags(cmd *cobra.Command, flags *pack.ModifyBuilderFlags) {
+	cmd.Flags().StringVarP(&flags.Tag, "tag", "t", "", "Name to save the modified builder image as (defaults to the builder image name)")
+	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Read the builder from and publish it to a registry")
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling the builder image before use")
+}
+
+func modifyBuilderFactory(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) (*pack.BuilderFactory, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("%s is not implemented on Windows", style.Symbol("builder"))
+	}
+	cfg, err := config.NewDefault()
+	if err != nil {
+		return nil, err
+	}
+	return &pack.BuilderFactory{
+		Logger:           logger,
+		Config:           cfg,
+		Fetcher:          fetcher,
+		BuildpackFetcher: bpFetcher,
+	}, nil
+}
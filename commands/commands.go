@@ -38,7 +38,7 @@ func multiValueHelp(name string) string {
 }
 
 func createCancellableContext() context.Context {
-	signals := make(chan os.Signal)
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 	ctx, cancel := context.WithCancel(context.Background())
 
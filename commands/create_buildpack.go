@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func CreateBuildpack(logger *logging.Logger) *cobra.Command {
+	var (
+		path string
+		opts buildpack.ScaffoldOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create-buildpack <buildpack-id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Create a new buildpack from a template",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			opts.ID = args[0]
+			if path == "" {
+				bp := buildpack.Buildpack{ID: opts.ID}
+				path = bp.EscapedID()
+			}
+			desc, err := buildpack.Scaffold(path, opts)
+			if err != nil {
+				return err
+			}
+			logger.Info("Successfully created buildpack %s in %s", style.Symbol(desc.Buildpack.ID+"@"+desc.Buildpack.Version), style.Symbol(path))
+			logger.Tip("Run %s to try it out", style.Symbol(fmt.Sprintf("pack build <image-name> --buildpack %s", path)))
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&path, "path", "p", "", "Directory to create the buildpack in (defaults to the buildpack id)")
+	cmd.Flags().StringVar(&opts.Version, "version", buildpack.DefaultScaffoldVersion, "Version of the buildpack")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "Name of the buildpack")
+	cmd.Flags().StringSliceVarP(&opts.Stacks, "stack", "s", []string{buildpack.DefaultScaffoldStack}, "ID of a stack the buildpack supports"+multiValueHelp("stack"))
+	cmd.Flags().StringVarP(&opts.Template, "template", "t", buildpack.DefaultScaffoldTemplate, "Template of the buildpack, one of "+strings.Join(buildpack.ScaffoldTemplates(), ", "))
+	AddHelpFlag(cmd, "create-buildpack")
+	return cmd
+}
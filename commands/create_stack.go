@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func CreateStack(logger *logging.Logger, imageFetcher *pack.ImageFetcher) *cobra.Command {
+	var flags pack.CreateStackFlags
+	ctx := createCancellableContext()
+
+	cmd := &cobra.Command{
+		Use:   "create-stack --stack-config <stack-config-path>",
+		Args:  cobra.NoArgs,
+		Short: "Create the build and run images of a stack from base images",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			creator := pack.StackCreator{
+				Logger:  logger,
+				Docker:  imageFetcher.Docker,
+				Fetcher: imageFetcher,
+			}
+			desc, err := creator.Create(ctx, flags)
+			if err != nil {
+				return err
+			}
+			logger.Info("Successfully created stack %s", style.Symbol(desc.ID))
+			logger.Info("  build image: %s", style.Symbol(desc.Build.Image))
+			logger.Info("  run image:   %s", style.Symbol(desc.Run.Image))
+			logger.Tip("Use them in the %s table of a builder.toml", style.Symbol("[stack]"))
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&flags.DescriptorPath, "stack-config", "s", "", "Path to stack TOML file (required)")
+	cmd.MarkFlagRequired("stack-config")
+	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish the images to registry")
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling base images before use")
+	AddHelpFlag(cmd, "create-stack")
+	return cmd
+}
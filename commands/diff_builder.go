@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/builder_differ.go github.com/buildpack/pack/commands BuilderDiffer
+type BuilderDiffer interface {
+	DiffBuilders(string, string, bool, bool) (*pack.BuilderDiff, error)
+}
+
+func DiffBuilder(logger *logging.Logger, differ BuilderDiffer) *cobra.Command {
+	var remote, oldRemote, newRemote, jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "diff-builder <old-builder-image-name> <new-builder-image-name>",
+		Args:  cobra.ExactArgs(2),
+		Short: "Show the differences between two builder images",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			diff, err := differ.DiffBuilders(args[0], args[1], !(remote || oldRemote), !(remote || newRemote))
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				out, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintln(logger.RawWriter(), string(out)); err != nil {
+					return err
+				}
+			} else {
+				printBuilderDiff(logger, diff)
+			}
+
+			if diff.HasChanges() {
+				return MakeSoftError()
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().BoolVar(&remote, "remote", false, "Compare builders in registry instead of local daemon")
+	cmd.Flags().BoolVar(&oldRemote, "old-remote", false, "Read the old builder from registry instead of local daemon")
+	cmd.Flags().BoolVar(&newRemote, "new-remote", false, "Read the new builder from registry instead of local daemon")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the differences as JSON")
+	AddHelpFlag(cmd, "diff-builder")
+	return cmd
+}
+
+func printBuilderDiff(logger *logging.Logger, diff *pack.BuilderDiff) {
+	logger.Info("Comparing %s to %s\n", style.Symbol(diff.Old), style.Symbol(diff.New))
+	if !diff.HasChanges() {
+		logger.Info("No differences")
+		return
+	}
+
+	printValueChange(logger, "Stack", diff.Stack)
+	printValueChange(logger, "Run Image", diff.RunImage)
+	if diff.RunImageMirrors != nil {
+		logger.Info("Run Image Mirrors:")
+		for _, mirror := range diff.RunImageMirrors.Added {
+			logger.Info("  + %s", mirror)
+		}
+		for _, mirror := range diff.RunImageMirrors.Removed {
+			logger.Info("  - %s", mirror)
+		}
+	}
+	printValueChange(logger, "Lifecycle Version", diff.LifecycleVersion)
+	printValueChange(logger, "Platform API", diff.PlatformAPI)
+
+	if len(diff.Buildpacks) > 0 {
+		logger.Info("Buildpacks:")
+		for _, bp := range diff.Buildpacks {
+			switch bp.Change {
+			case pack.BuildpackAdded:
+				logger.Info("  + %s %s", bp.ID, strings.Join(bp.NewVersions, ", "))
+			case pack.BuildpackRemoved:
+				logger.Info("  - %s %s", bp.ID, strings.Join(bp.OldVersions, ", "))
+			default:
+				logger.Info("  ~ %s %s -> %s", bp.ID, strings.Join(bp.OldVersions, ", "), strings.Join(bp.NewVersions, ", "))
+			}
+		}
+	}
+
+	if diff.Order != nil {
+		logger.Info("Detection Order:")
+		logger.Info("  old:")
+		printOrderRefs(logger, diff.Order.Old)
+		logger.Info("  new:")
+		printOrderRefs(logger, diff.Order.New)
+	}
+}
+
+func printValueChange(logger *logging.Logger, name string, change *pack.ValueChange) {
+	if change != nil {
+		logger.Info("%s: %s -> %s", name, displayValue(change.Old), displayValue(change.New))
+	}
+}
+
+func displayValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func printOrderRefs(logger *logging.Logger, groups [][]string) {
+	for i, group := range groups {
+		logger.Info("    Group #%d: %s", i+1, strings.Join(group, ", "))
+	}
+}
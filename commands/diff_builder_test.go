@@ -0,0 +1,125 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestDiffBuilderCommand(t *testing.T) {
+	spec.Run(t, "Commands", testDiffBuilderCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDiffBuilderCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockDiffer     *cmdmocks.MockBuilderDiffer
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDiffer = cmdmocks.NewMockBuilderDiffer(mockController)
+		command = commands.DiffBuilder(logging.NewLogger(&outBuf, &outBuf, false, false), mockDiffer)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#DiffBuilder", func() {
+		changed := &pack.BuilderDiff{
+			Old:              "some/builder:old",
+			New:              "some/builder:new",
+			RunImageMirrors:  &pack.ListChange{Added: []string{"gcr.io/some/run"}, Removed: []string{"quay.io/some/run"}},
+			LifecycleVersion: &pack.ValueChange{Old: "0.1.0", New: "0.2.0"},
+			Buildpacks: []pack.BuildpackChange{
+				{ID: "added.bp", Change: pack.BuildpackAdded, NewVersions: []string{"3.0.0"}},
+				{ID: "bumped.bp", Change: pack.BuildpackChanged, OldVersions: []string{"2.0.0"}, NewVersions: []string{"2.1.0"}},
+				{ID: "removed.bp", Change: pack.BuildpackRemoved, OldVersions: []string{"0.1.0"}},
+			},
+			Order: &pack.OrderChange{
+				Old: [][]string{{"bumped.bp@2.0.0"}},
+				New: [][]string{{"bumped.bp@2.1.0"}, {"added.bp@3.0.0"}},
+			},
+		}
+
+		when("the builders are the same", func() {
+			it("prints that there are no differences", func() {
+				mockDiffer.EXPECT().DiffBuilders("some/builder:old", "some/builder:new", true, true).Return(&pack.BuilderDiff{Old: "some/builder:old", New: "some/builder:new"}, nil)
+
+				command.SetArgs([]string{"some/builder:old", "some/builder:new"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "No differences")
+			})
+		})
+
+		when("the builders differ", func() {
+			it("prints the differences and returns a soft error", func() {
+				mockDiffer.EXPECT().DiffBuilders("some/builder:old", "some/builder:new", false, false).Return(changed, nil)
+
+				command.SetArgs([]string{"some/builder:old", "some/builder:new", "--remote"})
+				err := command.Execute()
+				h.AssertEq(t, commands.IsSoftError(err), true)
+				h.AssertContains(t, outBuf.String(), `Run Image Mirrors:
+  + gcr.io/some/run
+  - quay.io/some/run
+Lifecycle Version: 0.1.0 -> 0.2.0
+Buildpacks:
+  + added.bp 3.0.0
+  ~ bumped.bp 2.0.0 -> 2.1.0
+  - removed.bp 0.1.0
+Detection Order:
+  old:
+    Group #1: bumped.bp@2.0.0
+  new:
+    Group #1: bumped.bp@2.1.0
+    Group #2: added.bp@3.0.0
+`)
+			})
+
+			it("prints the differences as JSON", func() {
+				mockDiffer.EXPECT().DiffBuilders("some/builder:old", "some/builder:new", true, true).Return(changed, nil)
+
+				command.SetArgs([]string{"some/builder:old", "some/builder:new", "--json"})
+				err := command.Execute()
+				h.AssertEq(t, commands.IsSoftError(err), true)
+				h.AssertContains(t, outBuf.String(), `"lifecycleVersion": {
+    "old": "0.1.0",
+    "new": "0.2.0"
+  }`)
+				h.AssertNotContains(t, outBuf.String(), "Comparing")
+			})
+		})
+
+		when("only one builder is in the registry", func() {
+			it("reads the other one from the daemon", func() {
+				mockDiffer.EXPECT().DiffBuilders("some/builder:old", "some/builder:new", true, false).Return(&pack.BuilderDiff{Old: "some/builder:old", New: "some/builder:new"}, nil)
+
+				command.SetArgs([]string{"some/builder:old", "some/builder:new", "--new-remote"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+
+		when("a builder cannot be compared", func() {
+			it("returns the error", func() {
+				mockDiffer.EXPECT().DiffBuilders("some/builder:old", "some/builder:new", true, true).Return(nil, errors.New("some error"))
+
+				command.SetArgs([]string{"some/builder:old", "some/builder:new"})
+				h.AssertError(t, command.Execute(), "some error")
+			})
+		})
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/buildpack/pack/style"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"sort"
+	"strings"
 	"text/tabwriter"
 )
 
@@ -64,8 +66,42 @@ func inspectBuilderOutput(logger *logging.Logger, inspector BuilderInspector, im
 		return
 	}
 
+	if info.Description != "" {
+		logger.Info("Description: %s\n", info.Description)
+	}
+	if info.Maintainer != "" {
+		logger.Info("Maintainer: %s\n", info.Maintainer)
+	}
+
 	logger.Info("Stack: %s\n", info.Stack)
 
+	if info.LifecycleVersion != "" || info.PlatformAPI != "" || info.LifecycleImage != "" {
+		logger.Info("Lifecycle:")
+		if info.LifecycleVersion != "" {
+			logger.Info("  Version: %s", info.LifecycleVersion)
+		}
+		if info.PlatformAPI != "" {
+			logger.Info("  Platform API: %s", info.PlatformAPI)
+		}
+		if info.LifecycleImage != "" {
+			logger.Info("  Image: %s", info.LifecycleImage)
+		}
+		logger.Info("")
+	}
+
+	if len(info.BuildEnv) > 0 {
+		logger.Info("Build Env:")
+		var keys []string
+		for key := range info.BuildEnv {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			logger.Info("  %s=%s", key, info.BuildEnv[key])
+		}
+		logger.Info("")
+	}
+
 	logger.Info("Run Images:")
 	for _, r := range info.LocalRunImageMirrors {
 		logger.Info("  %s (user-configured)", r)
@@ -93,12 +129,12 @@ func inspectBuilderOutput(logger *logging.Logger, inspector BuilderInspector, im
 func logBuildpacksInfo(logger *logging.Logger, info *pack.BuilderInfo) {
 	buf := &bytes.Buffer{}
 	tabWriter := new(tabwriter.Writer).Init(buf, 0, 0, 8, ' ', 0)
-	if _, err := fmt.Fprint(tabWriter, "\n  ID\tVERSION\tLATEST\t"); err != nil {
+	if _, err := fmt.Fprint(tabWriter, "\n  ID\tVERSION\tLATEST\tSTACKS\t"); err != nil {
 		logger.Error(err.Error())
 	}
 
 	for _, bp := range info.Buildpacks {
-		if _, err := fmt.Fprint(tabWriter, fmt.Sprintf("\n  %s\t%s\t%t\t", bp.ID, bp.Version, bp.Latest)); err != nil {
+		if _, err := fmt.Fprint(tabWriter, fmt.Sprintf("\n  %s\t%s\t%t\t%s\t", bp.ID, bp.Version, bp.Latest, strings.Join(bp.Stacks, ", "))); err != nil {
 			logger.Error(err.Error())
 		}
 	}
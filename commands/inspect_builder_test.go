@@ -109,10 +109,13 @@ ERROR: failed to inspect image 'some/image': some local error
 		when("is successful", func() {
 			it.Before(func() {
 				buildpacks := []pack.BuildpackInfo{
-					{ID: "test.bp.one", Version: "1.0.0", Latest: true},
-					{ID: "test.bp.two", Version: "2.0.0", Latest: false},
+					{ID: "test.bp.one", Version: "1.0.0", Latest: true, Stacks: []string{"test.stack.id"}},
+					{ID: "test.bp.two", Version: "2.0.0", Latest: false, Stacks: []string{"test.stack.id", "other.stack.id"}},
 				}
 				remoteInfo := &pack.BuilderInfo{
+					Description:          "Some description",
+					Maintainer:           "Some maintainer",
+					BuildEnv:             map[string]string{"SOME_VAR": "some-value", "OTHER_VAR": "other-value"},
 					Stack:                "test.stack.id",
 					RunImage:             "some/run-image",
 					RunImageMirrors:      []string{"first/default", "second/default"},
@@ -153,8 +156,16 @@ ERROR: failed to inspect image 'some/image': some local error
 Remote
 ------
 
+Description: Some description
+
+Maintainer: Some maintainer
+
 Stack: test.stack.id
 
+Build Env:
+  OTHER_VAR=other-value
+  SOME_VAR=some-value
+
 Run Images:
   first/image (user-configured)
   second/image (user-configured)
@@ -163,9 +174,9 @@ Run Images:
   second/default
 
 Buildpacks:
-  ID                 VERSION        LATEST        
-  test.bp.one        1.0.0          true          
-  test.bp.two        2.0.0          false
+  ID                 VERSION        LATEST        STACKS               
+  test.bp.one        1.0.0          true          test.stack.id        
+  test.bp.two        2.0.0          false         test.stack.id, other.stack.id
 
 Detection Order:
   Group #1:
@@ -187,9 +198,9 @@ Run Images:
   second/local-default
 
 Buildpacks:
-  ID                 VERSION        LATEST        
-  test.bp.one        1.0.0          true          
-  test.bp.two        2.0.0          false
+  ID                 VERSION        LATEST        STACKS               
+  test.bp.one        1.0.0          true          test.stack.id        
+  test.bp.two        2.0.0          false         test.stack.id, other.stack.id
 
 Detection Order:
   Group #1:
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/run_image_checker.go github.com/buildpack/pack/commands RunImageChecker
+type RunImageChecker interface {
+	CheckRunImage(string, bool) (*pack.RunImageStatus, error)
+}
+
+func Outdated(logger *logging.Logger, checker RunImageChecker) *cobra.Command {
+	var remote bool
+
+	cmd := &cobra.Command{
+		Use:   "outdated <image-name>...",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "List app images that are not on their latest run image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			buf := &bytes.Buffer{}
+			tabWriter := new(tabwriter.Writer).Init(buf, 0, 0, 8, ' ', 0)
+			if _, err := fmt.Fprint(tabWriter, "IMAGE\tRUN IMAGE\tSTATUS\t"); err != nil {
+				return err
+			}
+
+			failed, outdated := 0, 0
+			for _, imageName := range args {
+				status, err := checker.CheckRunImage(imageName, !remote)
+				if err != nil {
+					logger.Error(errors.Wrapf(err, "failed to check image %s", style.Symbol(imageName)).Error())
+					failed++
+					continue
+				}
+				if status == nil {
+					logger.Error("image %s not found", style.Symbol(imageName))
+					failed++
+					continue
+				}
+
+				state := "up to date"
+				if status.Outdated {
+					state = "outdated"
+					outdated++
+				}
+				if _, err := fmt.Fprintf(tabWriter, "\n%s\t%s\t%s\t", status.Image, status.RunImage, state); err != nil {
+					return err
+				}
+			}
+
+			if err := tabWriter.Flush(); err != nil {
+				return err
+			}
+			logger.Info(buf.String())
+
+			if failed > 0 {
+				return fmt.Errorf("failed to check %d image(s)", failed)
+			}
+			if outdated > 0 {
+				logger.Info("\n%d of %d image(s) are outdated", outdated, len(args))
+				logger.Tip("Run %s to update an image", style.Symbol("pack rebase <image-name>"))
+				return MakeSoftError()
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().BoolVar(&remote, "remote", false, "Check images in registry instead of local daemon")
+	AddHelpFlag(cmd, "outdated")
+	return cmd
+}
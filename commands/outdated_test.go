@@ -0,0 +1,86 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestOutdatedCommand(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Commands", testOutdatedCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testOutdatedCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockChecker    *cmdmocks.MockRunImageChecker
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockChecker = cmdmocks.NewMockRunImageChecker(mockController)
+		command = commands.Outdated(logging.NewLogger(&outBuf, &outBuf, false, false), mockChecker)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#Outdated", func() {
+		when("every image is on its latest run image", func() {
+			it("lists the images as up to date", func() {
+				mockChecker.EXPECT().CheckRunImage("some/app", true).Return(&pack.RunImageStatus{Image: "some/app", RunImage: "some/run"}, nil)
+
+				command.SetArgs([]string{"some/app"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "some/app")
+				h.AssertContains(t, outBuf.String(), "up to date")
+				h.AssertNotContains(t, outBuf.String(), "outdated")
+			})
+		})
+
+		when("an image is outdated", func() {
+			it("lists it as outdated and returns a soft error", func() {
+				mockChecker.EXPECT().CheckRunImage("some/app", false).Return(&pack.RunImageStatus{Image: "some/app", RunImage: "some/run"}, nil)
+				mockChecker.EXPECT().CheckRunImage("other/app", false).Return(&pack.RunImageStatus{Image: "other/app", RunImage: "some/run", Outdated: true}, nil)
+
+				command.SetArgs([]string{"some/app", "other/app", "--remote"})
+				err := command.Execute()
+				h.AssertEq(t, commands.IsSoftError(err), true)
+				h.AssertContains(t, outBuf.String(), "1 of 2 image(s) are outdated")
+				h.AssertContains(t, outBuf.String(), "Tip: Run 'pack rebase <image-name>' to update an image")
+			})
+		})
+
+		when("an image cannot be checked", func() {
+			it("checks the other images and returns an error", func() {
+				mockChecker.EXPECT().CheckRunImage("missing/app", true).Return(nil, nil)
+				mockChecker.EXPECT().CheckRunImage("broken/app", true).Return(nil, errors.New("some error"))
+				mockChecker.EXPECT().CheckRunImage("some/app", true).Return(&pack.RunImageStatus{Image: "some/app", RunImage: "some/run", Outdated: true}, nil)
+
+				command.SetArgs([]string{"missing/app", "broken/app", "some/app"})
+				err := command.Execute()
+				h.AssertError(t, err, "failed to check 2 image(s)")
+				h.AssertEq(t, commands.IsSoftError(err), false)
+				h.AssertContains(t, outBuf.String(), "image 'missing/app' not found")
+				h.AssertContains(t, outBuf.String(), "failed to check image 'broken/app': some error")
+				h.AssertContains(t, outBuf.String(), "outdated")
+			})
+		})
+	})
+}
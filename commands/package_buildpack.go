@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func PackageBuildpack(logger *logging.Logger, imageFetcher *pack.ImageFetcher) *cobra.Command {
+	var flags pack.PackageBuildpackFlags
+	ctx := createCancellableContext()
+
+	cmd := &cobra.Command{
+		Use:   "package-buildpack --path <buildpack-path> [--archive <file.tgz>] [--image <image-name>]",
+		Args:  cobra.NoArgs,
+		Short: "Package a buildpack as a .tgz archive or an image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			packager := pack.BuildpackPackager{
+				Logger:  logger,
+				Docker:  imageFetcher.Docker,
+				Factory: imageFetcher.Factory,
+			}
+			desc, err := packager.Package(ctx, flags)
+			if err != nil {
+				return err
+			}
+			bp := style.Symbol(desc.Buildpack.ID + "@" + desc.Buildpack.Version)
+			if flags.ArchivePath != "" {
+				logger.Info("Successfully packaged buildpack %s as archive %s", bp, style.Symbol(flags.ArchivePath))
+			}
+			if flags.ImageName != "" {
+				logger.Info("Successfully packaged buildpack %s as image %s", bp, style.Symbol(flags.ImageName))
+				logger.Tip("Use it with %s", style.Symbol("--buildpack docker://"+flags.ImageName))
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&flags.BuildpackDir, "path", "p", ".", "Path to the buildpack directory")
+	cmd.Flags().StringVar(&flags.ArchivePath, "archive", "", "Write the buildpack to this .tgz file")
+	cmd.Flags().StringVar(&flags.ImageName, "image", "", "Package the buildpack as this image")
+	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish the image to registry")
+	AddHelpFlag(cmd, "package-buildpack")
+	return cmd
+}
@@ -42,6 +42,7 @@ func Rebase(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish to registry")
 	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling app and run images before use")
 	cmd.Flags().StringVar(&flags.RunImage, "run-image", "", "Run image to use for rebasing")
+	cmd.Flags().StringVarP(&flags.Tag, "tag", "t", "", "Save the rebased image under a new name instead of overwriting the original\nWith --publish, layers are mounted when the new name is in the same registry,\n  and copied otherwise")
 	AddHelpFlag(cmd, "rebase")
 	return cmd
 }
@@ -8,7 +8,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func SetDefaultBuilder(logger *logging.Logger) *cobra.Command {
+func SetDefaultBuilder(logger *logging.Logger, inspector BuilderInspector) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set-default-builder <builder-name>",
 		Short: "Set default builder used by other commands",
@@ -17,7 +17,7 @@ func SetDefaultBuilder(logger *logging.Logger) *cobra.Command {
 		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 || args[0] == "" {
 				logger.Info(fmt.Sprintf("Usage:\n\t%s\n", cmd.UseLine()))
-				suggestBuilders(logger)
+				suggestBuilders(logger, inspector)
 				return nil
 			}
 
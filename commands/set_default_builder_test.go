@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 	"github.com/spf13/cobra"
 
+	"github.com/buildpack/pack"
 	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
 	"github.com/buildpack/pack/logging"
 	h "github.com/buildpack/pack/testhelpers"
 )
@@ -23,11 +26,21 @@ func testSetDefaultBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 		command        *cobra.Command
 		logger         *logging.Logger
 		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockInspector  *cmdmocks.MockBuilderInspector
 	)
 
 	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockInspector = cmdmocks.NewMockBuilderInspector(mockController)
+		mockInspector.EXPECT().InspectBuilder("heroku/buildpacks", true).Return(&pack.BuilderInfo{Description: "some local description"}, nil).AnyTimes()
+		mockInspector.EXPECT().InspectBuilder(gomock.Any(), true).Return(nil, nil).AnyTimes()
 		logger = logging.NewLogger(&outBuf, &outBuf, false, false)
-		command = commands.SetDefaultBuilder(logger)
+		command = commands.SetDefaultBuilder(logger, mockInspector)
+	})
+
+	it.After(func() {
+		mockController.Finish()
 	})
 
 	when("#SetDefaultBuilder", func() {
@@ -39,6 +52,15 @@ func testSetDefaultBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("a suggested builder is available locally", func() {
+			it("displays the description it declares", func() {
+				command.SetArgs([]string{})
+				h.AssertNil(t, command.Execute())
+				h.AssertMatch(t, outBuf.String(), `Heroku:\s+'heroku/buildpacks'\s+some local description`)
+				h.AssertMatch(t, outBuf.String(), `Cloud Foundry:\s+'cloudfoundry/cnb:bionic'\s+small base image with Java & Node.js`)
+			})
+		})
+
 		when("empty builder name is provided", func() {
 			it("display suggested builders", func() {
 				command.SetArgs([]string{})
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/cache"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func TestBuildpack(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+	var (
+		flags      pack.TestBuildpackFlags
+		reportPath string
+	)
+	ctx := createCancellableContext()
+
+	cmd := &cobra.Command{
+		Use:   "test-buildpack <buildpack-path> --fixture <app-path>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Test a buildpack against a fixture app",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.BuildpackDir = args[0]
+
+			dockerClient, err := docker.New()
+			if err != nil {
+				return err
+			}
+			repoName, err := pack.RepositoryName(logger, &pack.BuildFlags{AppDir: flags.FixtureDir})
+			if err != nil {
+				return err
+			}
+			cacheObj, err := cache.New(repoName, dockerClient)
+			if err != nil {
+				return err
+			}
+			bf, err := pack.DefaultBuildFactory(logger, cacheObj, dockerClient, fetcher)
+			if err != nil {
+				return err
+			}
+			if bf.Config.DefaultBuilder == "" && flags.Builder == "" {
+				suggestSettingBuilder(logger)
+				return MakeSoftError()
+			}
+
+			result, err := bf.TestBuildpack(ctx, flags)
+			if err != nil {
+				return err
+			}
+			if reportPath != "" {
+				report, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := ioutil.WriteFile(reportPath, append(report, '\n'), 0644); err != nil {
+					return err
+				}
+			}
+
+			printTestResult(logger, result)
+			if !result.Passed {
+				return MakeSoftError()
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&flags.FixtureDir, "fixture", "f", "", "Path to the fixture app (required)")
+	cmd.MarkFlagRequired("fixture")
+	cmd.Flags().StringVar(&flags.ExpectationsPath, "expectations", "", "Path to the expectations TOML file (defaults to 'expectations.toml' in the fixture)")
+	cmd.Flags().StringVar(&flags.Builder, "builder", "", "Builder (defaults to builder configured by 'set-default-builder')")
+	cmd.Flags().StringArrayVarP(&flags.Env, "env", "e", []string{}, "Build-time environment variable, in the form 'VAR=VALUE' or 'VAR'"+multiValueHelp("env"))
+	cmd.Flags().StringVar(&flags.EnvFile, "env-file", "", "Build-time environment variables file")
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling builder and run images before use")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write the results as JSON to this file")
+	AddHelpFlag(cmd, "test-buildpack")
+	return cmd
+}
+
+func printTestResult(logger *logging.Logger, result *buildpack.TestResult) {
+	detect := "fail"
+	if result.Detected {
+		detect = "pass"
+	}
+	logger.Info("Buildpack: %s", style.Symbol(result.Buildpack))
+	logger.Info("Fixture:   %s", style.Symbol(result.Fixture))
+	logger.Info("Detect:    %s", detect)
+	if result.Detected {
+		logger.Info("Plan:      %s", strings.Join(result.Plan, ", "))
+		var layers []string
+		for _, layer := range result.Layers {
+			layers = append(layers, layerSummary(layer))
+		}
+		logger.Info("Layers:    %s", strings.Join(layers, ", "))
+		logger.Info("Processes: %s", strings.Join(result.Processes, ", "))
+	}
+
+	if result.Passed {
+		logger.Info("\nAll expectations met")
+		return
+	}
+	if result.DetectOutput != "" {
+		logger.Info("\nDetect output:\n%s", result.DetectOutput)
+	}
+	if result.BuildOutput != "" {
+		logger.Info("\nBuild output:\n%s", result.BuildOutput)
+	}
+	logger.Error("%d expectation(s) not met:", len(result.Failures))
+	for _, failure := range result.Failures {
+		logger.Error("  - %s", failure)
+	}
+}
+
+func layerSummary(layer buildpack.LayerResult) string {
+	var flags []string
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{{"build", layer.Build}, {"launch", layer.Launch}, {"cache", layer.Cache}} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	return layer.Name + " [" + strings.Join(flags, ",") + "]"
+}
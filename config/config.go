@@ -12,6 +12,7 @@ import (
 type Config struct {
 	RunImages      []RunImage `toml:"run-images"`
 	DefaultBuilder string     `toml:"default-builder-image,omitempty"`
+	Downloads      *Downloads `toml:"downloads,omitempty"`
 	configPath     string
 }
 
@@ -20,6 +21,21 @@ type RunImage struct {
 	Mirrors []string `toml:"mirrors"`
 }
 
+// Downloads configures how buildpacks are downloaded over HTTP
+type Downloads struct {
+	Timeout string         `toml:"timeout,omitempty"`
+	Retries *int           `toml:"retries,omitempty"`
+	Hosts   []DownloadHost `toml:"hosts,omitempty"`
+}
+
+// DownloadHost holds the credentials sent to a host, either a bearer token or a username and password
+type DownloadHost struct {
+	Host     string `toml:"host"`
+	Token    string `toml:"token,omitempty"`
+	Username string `toml:"username,omitempty"`
+	Password string `toml:"password,omitempty"`
+}
+
 func NewDefault() (*Config, error) {
 	packHome := os.Getenv("PACK_HOME")
 	if packHome == "" {
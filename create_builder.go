@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpack/lifecycle"
@@ -14,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/build"
 	"github.com/buildpack/pack/builder"
 	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/config"
@@ -27,8 +30,15 @@ type BuilderConfig struct {
 	Groups          []lifecycle.BuildpackGroup
 	Repo            lcimg.Image
 	BuilderDir      string // original location of builder.toml, used for interpreting relative paths in buildpack URIs
+	StackID         string
 	RunImage        string
 	RunImageMirrors []string
+	Lifecycle       builder.LifecycleMetadata
+	Description     string
+	Maintainer      string
+	BuildEnv        map[string]string
+	// InheritedBuildpacks are already in Repo, when it is the parent builder image that builder.toml extends
+	InheritedBuildpacks []builder.BuildpackMetadata
 }
 
 type BuilderFactory struct {
@@ -49,46 +59,91 @@ func (f *BuilderFactory) BuilderConfigFromFlags(ctx context.Context, flags Creat
 	builderConfig := BuilderConfig{}
 	builderConfig.BuilderDir = filepath.Dir(flags.BuilderTomlPath)
 
-	builderTOML := &builder.TOML{}
-	_, err := toml.DecodeFile(flags.BuilderTomlPath, &builderTOML)
+	builderTOML, err := readBuilderTOML(flags.BuilderTomlPath, map[string]bool{})
 	if err != nil {
-		return BuilderConfig{}, fmt.Errorf(`failed to decode builder config from file %s: %s`, flags.BuilderTomlPath, err)
-	}
-
-	if err := validateBuilderTOML(builderTOML); err != nil {
 		return BuilderConfig{}, err
 	}
 
-	baseImage := builderTOML.Stack.BuildImage
-	builderConfig.RunImage = builderTOML.Stack.RunImage
-	builderConfig.RunImageMirrors = builderTOML.Stack.RunImageMirrors
-	if flags.Publish {
-		builderConfig.Repo, err = f.Fetcher.FetchRemoteImage(baseImage)
-	} else {
-		if !flags.NoPull {
-			builderConfig.Repo, err = f.Fetcher.FetchUpdatedLocalImage(ctx, baseImage, f.Logger.RawVerboseWriter())
-		} else {
-			builderConfig.Repo, err = f.Fetcher.FetchLocalImage(baseImage)
+	var parentImage lcimg.Image
+	if builderTOML.Extends != "" && !extendsBuilderTOML(builderTOML.Extends) {
+		builderTOML, parentImage, builderConfig.InheritedBuildpacks, err = f.extendBuilderImage(ctx, builderTOML, flags.Publish, flags.NoPull)
+		if err != nil {
+			return BuilderConfig{}, err
 		}
 	}
-	if err != nil {
-		return BuilderConfig{}, errors.Wrapf(err, "opening base image: %s", baseImage)
-	}
-	builderConfig.Repo.Rename(flags.RepoName)
 
-	builderConfig.Groups = builderTOML.Groups
+	if err := validateBuilderTOML(builderTOML); err != nil {
+		return BuilderConfig{}, err
+	}
 
 	for _, b := range builderTOML.Buildpacks {
 		fetchedBuildpack, err := f.BuildpackFetcher.FetchBuildpack(builderConfig.BuilderDir, b)
 		if err != nil {
 			return BuilderConfig{}, err
 		}
+		data, err := f.buildpackData(fetchedBuildpack, fetchedBuildpack.Dir)
+		if err != nil {
+			return BuilderConfig{}, err
+		}
+		fetchedBuildpack.Version = data.BP.Version
+		for _, stack := range data.Stacks {
+			fetchedBuildpack.Stacks = append(fetchedBuildpack.Stacks, stack.ID)
+		}
 		builderConfig.Buildpacks = append(builderConfig.Buildpacks, fetchedBuildpack)
 	}
+	builderConfig.Groups = builderTOML.Groups
+	available := builderConfig.Buildpacks
+	inherited := builderConfig.InheritedBuildpacks[:0]
+	for _, bp := range builderConfig.InheritedBuildpacks {
+		if !containsBuildpack(builderConfig.Buildpacks, bp.ID, bp.Version) {
+			inherited = append(inherited, bp)
+			available = append(available, buildpack.Buildpack{ID: bp.ID, Version: bp.Version, Latest: bp.Latest})
+		}
+	}
+	builderConfig.InheritedBuildpacks = inherited
+	if err := resolveGroups(flags.BuilderTomlPath, builderConfig.Groups, available); err != nil {
+		return BuilderConfig{}, err
+	}
+
+	baseImage := builderTOML.Stack.BuildImage
+	builderConfig.StackID = builderTOML.Stack.ID
+	builderConfig.RunImage = builderTOML.Stack.RunImage
+	builderConfig.RunImageMirrors = builderTOML.Stack.RunImageMirrors
+	builderConfig.Description = builderTOML.Builder.Description
+	builderConfig.Maintainer = builderTOML.Builder.Maintainer
+	builderConfig.BuildEnv = builderTOML.Build.Env
+	builderConfig.Lifecycle = builder.LifecycleMetadata{
+		Version:     builderTOML.Lifecycle.Version,
+		PlatformAPI: builderTOML.Lifecycle.PlatformAPI,
+		Image:       builderTOML.Lifecycle.Image,
+	}
+	if parentImage != nil {
+		builderConfig.Repo = parentImage
+	} else {
+		builderConfig.Repo, err = f.fetchImage(ctx, baseImage, flags.Publish, flags.NoPull)
+		if err != nil {
+			return BuilderConfig{}, errors.Wrapf(err, "opening base image: %s", baseImage)
+		}
+	}
+	builderConfig.Repo.Rename(flags.RepoName)
 	return builderConfig, nil
 }
 
+func (f *BuilderFactory) fetchImage(ctx context.Context, name string, publish, noPull bool) (lcimg.Image, error) {
+	if publish {
+		return f.Fetcher.FetchRemoteImage(name)
+	}
+	if !noPull {
+		return f.Fetcher.FetchUpdatedLocalImage(ctx, name, f.Logger.RawVerboseWriter())
+	}
+	return f.Fetcher.FetchLocalImage(name)
+}
+
 func (f *BuilderFactory) Create(config BuilderConfig) error {
+	if err := f.checkBuildImageStack(config); err != nil {
+		return err
+	}
+
 	tmpDir, err := ioutil.TempDir("", "create-builder")
 	if err != nil {
 		return fmt.Errorf(`failed to create temporary directory: %s`, err)
@@ -103,16 +158,20 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 		return fmt.Errorf(`failed append order.toml layer to image: %s`, err)
 	}
 
-	buildpacksMetadata := make([]builder.BuildpackMetadata, 0, len(config.Buildpacks))
+	buildpacksMetadata := make([]builder.BuildpackMetadata, 0, len(config.InheritedBuildpacks)+len(config.Buildpacks))
+	buildpacksMetadata = append(buildpacksMetadata, config.InheritedBuildpacks...)
 	for _, buildpack := range config.Buildpacks {
 		tarFile, err := f.buildpackLayer(tmpDir, &buildpack, config.BuilderDir)
 		if err != nil {
 			return fmt.Errorf(`failed to generate layer for buildpack %s: %s`, style.Symbol(buildpack.ID), err)
 		}
+		if err := f.checkStack(buildpack, config.StackID); err != nil {
+			return err
+		}
 		if err := config.Repo.AddLayer(tarFile); err != nil {
 			return fmt.Errorf(`failed append buildpack layer to image: %s`, err)
 		}
-		buildpacksMetadata = append(buildpacksMetadata, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version, Latest: buildpack.Latest})
+		buildpacksMetadata = append(buildpacksMetadata, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version, Latest: buildpack.Latest, Stacks: buildpack.Stacks})
 	}
 
 	tarFile, err := f.latestLayer(config.Buildpacks, tmpDir, config.BuilderDir)
@@ -123,13 +182,9 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 		return fmt.Errorf(`failed append latest link layer to image: %s`, err)
 	}
 
-	groupsMetadata := make([]builder.GroupMetadata, 0, len(config.Groups))
-	for _, group := range config.Groups {
-		groupBuildpacks := make([]builder.BuildpackMetadata, 0, len(group.Buildpacks))
-		for _, buildpack := range group.Buildpacks {
-			groupBuildpacks = append(groupBuildpacks, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version})
-		}
-		groupsMetadata = append(groupsMetadata, builder.GroupMetadata{Buildpacks: groupBuildpacks})
+	lifecycleMetadata := config.Lifecycle
+	if lifecycleMetadata.PlatformAPI == "" {
+		lifecycleMetadata.PlatformAPI = build.DefaultPlatformAPI
 	}
 
 	jsonBytes, err := json.Marshal(&builder.Metadata{
@@ -139,8 +194,12 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 				Mirrors: config.RunImageMirrors,
 			},
 		},
-		Buildpacks: buildpacksMetadata,
-		Groups:     groupsMetadata,
+		Buildpacks:  buildpacksMetadata,
+		Groups:      groupsMetadata(config.Groups),
+		Lifecycle:   lifecycleMetadata,
+		Description: config.Description,
+		Maintainer:  config.Maintainer,
+		BuildEnv:    config.BuildEnv,
 	})
 	if err != nil {
 		return fmt.Errorf(`failed marshal builder image metadata: %s`, err)
@@ -169,6 +228,18 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 	return nil
 }
 
+func groupsMetadata(groups []lifecycle.BuildpackGroup) []builder.GroupMetadata {
+	metadata := make([]builder.GroupMetadata, 0, len(groups))
+	for _, group := range groups {
+		groupBuildpacks := make([]builder.BuildpackMetadata, 0, len(group.Buildpacks))
+		for _, buildpack := range group.Buildpacks {
+			groupBuildpacks = append(groupBuildpacks, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version})
+		}
+		metadata = append(metadata, builder.GroupMetadata{Buildpacks: groupBuildpacks})
+	}
+	return metadata
+}
+
 type order struct {
 	Groups []lifecycle.BuildpackGroup `toml:"groups"`
 }
@@ -231,6 +302,9 @@ type BuildpackData struct {
 		ID      string `toml:"id"`
 		Version string `toml:"version"`
 	} `toml:"buildpack"`
+	Stacks []struct {
+		ID string `toml:"id"`
+	} `toml:"stacks"`
 }
 
 // buildpackLayer creates and returns the location of a tgz file for a buildpack layer. That file will reside in the `dest` directory.
@@ -252,6 +326,10 @@ func (f *BuilderFactory) buildpackLayer(dest string, buildpack *buildpack.Buildp
 	}
 
 	buildpack.Version = bp.Version
+	buildpack.Stacks = nil
+	for _, stack := range data.Stacks {
+		buildpack.Stacks = append(buildpack.Stacks, stack.ID)
+	}
 	tarFile := filepath.Join(dest, fmt.Sprintf("%s.%s.tar", buildpack.EscapedID(), bp.Version))
 	if err := archive.CreateTar(tarFile, dir, filepath.Join("/buildpacks", buildpack.EscapedID(), bp.Version), 0, 0); err != nil {
 		return "", err
@@ -259,6 +337,38 @@ func (f *BuilderFactory) buildpackLayer(dest string, buildpack *buildpack.Buildp
 	return tarFile, err
 }
 
+// checkBuildImageStack checks that the build image belongs to the stack that builder.toml declares, which buildpacks
+// are then checked against. Build images without a stack label are only warned about.
+func (f *BuilderFactory) checkBuildImageStack(config BuilderConfig) error {
+	imageStackID, err := config.Repo.Label(stack.IDLabel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read stack of build image %s", style.Symbol(config.Repo.Name()))
+	}
+	if imageStackID == "" {
+		f.Logger.Info("Warning: build image %s is missing label %s", style.Symbol(config.Repo.Name()), style.Symbol(stack.IDLabel))
+		return nil
+	}
+	if imageStackID != config.StackID {
+		return fmt.Errorf("build image %s has stack %s, but %s is %s", style.Symbol(config.Repo.Name()), style.Symbol(imageStackID), style.Symbol("stack.id"), style.Symbol(config.StackID))
+	}
+	return nil
+}
+
+// checkStack rejects a buildpack that declares the stacks it supports when the builder's stack is not one of them.
+// Buildpacks that declare no stacks are only warned about.
+func (f *BuilderFactory) checkStack(bp buildpack.Buildpack, stackID string) error {
+	if len(bp.Stacks) == 0 {
+		f.Logger.Info("Warning: buildpack %s does not declare the stacks it supports", style.Symbol(bp.ID))
+		return nil
+	}
+	for _, id := range bp.Stacks {
+		if id == stackID {
+			return nil
+		}
+	}
+	return fmt.Errorf("buildpack %s does not support stack %s, it only supports: %s", style.Symbol(bp.ID), style.Symbol(stackID), strings.Join(bp.Stacks, ", "))
+}
+
 func (f *BuilderFactory) buildpackData(buildpack buildpack.Buildpack, dir string) (*BuildpackData, error) {
 	data := &BuildpackData{}
 	_, err := toml.DecodeFile(filepath.Join(dir, "buildpack.toml"), &data)
@@ -313,5 +423,134 @@ func validateBuilderTOML(builderTOML *builder.TOML) error {
 	if builderTOML.Stack.RunImage == "" {
 		return errors.New("stack.run-image is required")
 	}
+
+	if api := builderTOML.Lifecycle.PlatformAPI; api != "" && !build.SupportsPlatformAPI(api) {
+		return fmt.Errorf("lifecycle.platform-api %s is not supported (supported platform APIs: %s)", style.Symbol(api), strings.Join(build.SupportedPlatformAPIs(), ", "))
+	}
+	return nil
+}
+
+// resolveGroups checks that every group entry refers to a buildpack the builder provides, either by version, by a
+// version constraint such as "^1.2" or as "latest". It modifies groups: the Version of each entry with a constraint is
+// replaced by the highest matching version of that buildpack, which is what callers write to the builder. All problems
+// are reported together, with the line of builder.toml they come from.
+func resolveGroups(builderTOMLPath string, groups []lifecycle.BuildpackGroup, buildpacks []buildpack.Buildpack) error {
+	lines, err := locateBuilderTOMLEntries(builderTOMLPath, len(buildpacks), groups)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	report := func(line int, format string, a ...interface{}) {
+		msg := fmt.Sprintf(format, a...)
+		if line > 0 {
+			msg = fmt.Sprintf("line %d: %s", line, msg)
+		}
+		problems = append(problems, msg)
+	}
+
+	versions := map[string][]string{}
+	latest := map[string]bool{}
+	for i, bp := range buildpacks {
+		versions[bp.ID] = append(versions[bp.ID], bp.Version)
+		if bp.Latest {
+			if latest[bp.ID] {
+				report(lines.buildpacks[i], "buildpack %s is marked latest more than once", style.Symbol(bp.ID))
+			}
+			latest[bp.ID] = true
+		}
+	}
+
+	for i, group := range groups {
+		for j, entry := range group.Buildpacks {
+			line := lines.groups[i][j]
+			ref := style.Symbol(entry.ID + "@" + entry.Version)
+			switch available, declared := versions[entry.ID]; {
+			case entry.Version == "":
+				report(line, "group %d: buildpack %s must provide a version", i+1, style.Symbol(entry.ID))
+			case !declared:
+				report(line, "group %d: buildpack %s is not declared in [[buildpacks]]", i+1, ref)
+			case entry.Version == "latest":
+				if !latest[entry.ID] {
+					report(line, "group %d: buildpack %s is not marked latest by any [[buildpacks]] entry", i+1, ref)
+				}
+			case buildpack.IsVersionConstraint(entry.Version):
+				resolved, err := buildpack.ResolveVersion(entry.Version, available)
+				if err != nil {
+					report(line, "group %d: buildpack %s: %s, available versions: %s", i+1, style.Symbol(entry.ID), err, strings.Join(available, ", "))
+					continue
+				}
+				entry.Version = resolved
+			case !containsString(available, entry.Version):
+				report(line, "group %d: buildpack %s is not declared in [[buildpacks]], available versions: %s", i+1, ref, strings.Join(available, ", "))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid builder config %s:\n  %s", style.Symbol(builderTOMLPath), strings.Join(problems, "\n  "))
+	}
 	return nil
 }
+
+var idKeyPattern = regexp.MustCompile(`(^|[{,\s])id\s*=`)
+
+type builderTOMLLines struct {
+	buildpacks []int
+	groups     [][]int
+}
+
+// locateBuilderTOMLEntries finds the line of each [[buildpacks]] entry and of each buildpack in [[groups]] entries, so
+// that problems can be reported with their line. Lines that cannot be found are 0.
+func locateBuilderTOMLEntries(path string, numBuildpacks int, groups []lifecycle.BuildpackGroup) (builderTOMLLines, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return builderTOMLLines{}, err
+	}
+
+	var (
+		found      builderTOMLLines
+		groupLines [][]int
+		section    string
+	)
+	for i, line := range strings.Split(string(contents), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "[[buildpacks]]"):
+			section = "buildpacks"
+			found.buildpacks = append(found.buildpacks, i+1)
+		case strings.HasPrefix(trimmed, "[[groups]]"):
+			section = "groups"
+			groupLines = append(groupLines, nil)
+		case strings.HasPrefix(trimmed, "[[groups.buildpacks]]"):
+		case strings.HasPrefix(trimmed, "["):
+			section = ""
+		case section == "groups":
+			last := len(groupLines) - 1
+			for range idKeyPattern.FindAllString(trimmed, -1) {
+				groupLines[last] = append(groupLines[last], i+1)
+			}
+		}
+	}
+
+	found.groups = make([][]int, len(groups))
+	for i, group := range groups {
+		found.groups[i] = make([]int, len(group.Buildpacks))
+		if i < len(groupLines) {
+			copy(found.groups[i], groupLines[i])
+		}
+	}
+	if len(found.buildpacks) < numBuildpacks {
+		found.buildpacks = append(found.buildpacks, make([]int, numBuildpacks-len(found.buildpacks))...)
+	}
+	return found, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/builder"
 	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/logging"
@@ -91,8 +92,12 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				checkBuildpacks(t, cfg.Buildpacks)
 				checkGroups(t, cfg.Groups)
 				h.AssertEq(t, cfg.BuilderDir, "testdata")
+				h.AssertEq(t, cfg.StackID, "com.example.stack")
 				h.AssertEq(t, cfg.RunImage, "some/run")
 				h.AssertEq(t, cfg.RunImageMirrors, []string{"gcr.io/some/run2"})
+				h.AssertEq(t, cfg.Description, "Some builder description")
+				h.AssertEq(t, cfg.Maintainer, "Some Maintainer <maintainer@example.com>")
+				h.AssertEq(t, cfg.BuildEnv, map[string]string{"HTTP_PROXY": "http://proxy.example.com"})
 			})
 
 			it("doesn't pull a new base image when --no-pull flag is provided", func() {
@@ -203,6 +208,298 @@ build-image = "packs/build:v3alpha2"
 				})
 				h.AssertError(t, err, "stack.run-image is required")
 			})
+
+			it("resolves version constraints in groups to the highest matching buildpack", func() {
+				bpDir, err := filepath.Abs("testdata")
+				h.AssertNil(t, err)
+				file, err := ioutil.TempFile("", "builder.toml")
+				h.AssertNil(t, err)
+				defer os.Remove(file.Name())
+
+				_, err = file.WriteString(fmt.Sprintf(`[[buildpacks]]
+id = "some.bp1"
+uri = "%[1]s/some-path-1"
+
+[[buildpacks]]
+id = "some/bp2"
+uri = "%[1]s/some-path-2"
+
+[[buildpacks]]
+id = "some/bp2"
+uri = "%[1]s/some-latest-path-2"
+
+[[groups]]
+buildpacks = [
+  { id = "some.bp1", version = "^1" },
+  { id = "some/bp2", version = "~1.2" },
+]
+
+[[groups]]
+buildpacks = [{ id = "some/bp2", version = ">=1.2.0, <1.2.5" }]
+
+[stack]
+id = "com.example.stack"
+build-image = "some/build"
+run-image = "some/run"
+`, bpDir))
+				h.AssertNil(t, err)
+				file.Close()
+
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Rename("some/image")
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: file.Name(),
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.Groups, []lifecycle.BuildpackGroup{
+					{Buildpacks: []*lifecycle.Buildpack{{ID: "some.bp1", Version: "1.2.3"}, {ID: "some/bp2", Version: "1.2.5"}}},
+					{Buildpacks: []*lifecycle.Buildpack{{ID: "some/bp2", Version: "1.2.4"}}},
+				})
+			})
+
+			it("reports every group entry that the buildpacks do not provide before fetching the base image", func() {
+				bpDir, err := filepath.Abs("testdata")
+				h.AssertNil(t, err)
+				file, err := ioutil.TempFile("", "builder.toml")
+				h.AssertNil(t, err)
+				defer os.Remove(file.Name())
+
+				_, err = file.WriteString(fmt.Sprintf(`[[buildpacks]]
+id = "some.bp1"
+uri = "%[1]s/some-path-1"
+latest = true
+
+[[buildpacks]]
+id = "some.bp1"
+uri = "%[1]s/some-path-1"
+latest = true
+
+[[buildpacks]]
+id = "some/bp2"
+uri = "%[1]s/some-path-2"
+
+[[groups]]
+buildpacks = [
+  { id = "some.bp1", version = "latest" },
+  { id = "some/bp2", version = "9.9.9" },
+]
+
+[[groups]]
+buildpacks = [{ id = "some/bp2", version = "latest" }, { id = "missing.bp", version = "1.0.0" }]
+
+[[groups]]
+buildpacks = [{ id = "some.bp1", version = "^2.0" }]
+
+[stack]
+id = "com.example.stack"
+build-image = "some/build"
+run-image = "some/run"
+`, bpDir))
+				h.AssertNil(t, err)
+				file.Close()
+
+				_, err = factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: file.Name(),
+				})
+				h.AssertError(t, err, fmt.Sprintf(`invalid builder config '%s':
+  line 6: buildpack 'some.bp1' is marked latest more than once
+  line 18: group 1: buildpack 'some/bp2@9.9.9' is not declared in [[buildpacks]], available versions: 1.2.4
+  line 22: group 2: buildpack 'some/bp2@latest' is not marked latest by any [[buildpacks]] entry
+  line 22: group 2: buildpack 'missing.bp@1.0.0' is not declared in [[buildpacks]]
+  line 25: group 3: buildpack 'some.bp1': no version matches '^2.0', available versions: 1.2.3, 1.2.3`, file.Name()))
+			})
+
+			when("builder.toml extends another builder.toml", func() {
+				it("adds to the parent's buildpacks and mirrors, and overrides its groups", func() {
+					parentPath, err := filepath.Abs(filepath.Join("testdata", "builder.toml"))
+					h.AssertNil(t, err)
+					file, err := ioutil.TempFile("", "builder.toml")
+					h.AssertNil(t, err)
+					defer os.Remove(file.Name())
+					_, err = file.WriteString(fmt.Sprintf(`extends = %q
+
+[[groups]]
+buildpacks = [{ id = "some/bp2", version = "latest" }]
+
+[stack]
+run-image-mirrors = ["gcr.io/some/run2", "gcr.io/child/run"]
+`, parentPath))
+					h.AssertNil(t, err)
+					file.Close()
+
+					mockBaseImage := mocks.NewMockImage(mockController)
+					mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+					mockBaseImage.EXPECT().Rename("some/image")
+
+					cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+						RepoName:        "some/image",
+						BuilderTomlPath: file.Name(),
+					})
+					h.AssertNil(t, err)
+					h.AssertEq(t, len(cfg.Buildpacks), 3)
+					h.AssertEq(t, cfg.Buildpacks[0].Dir, filepath.Join(filepath.Dir(parentPath), "some-path-1"))
+					h.AssertEq(t, cfg.Groups, []lifecycle.BuildpackGroup{{Buildpacks: []*lifecycle.Buildpack{{ID: "some/bp2", Version: "latest"}}}})
+					h.AssertEq(t, cfg.StackID, "com.example.stack")
+					h.AssertEq(t, cfg.RunImage, "some/run")
+					h.AssertEq(t, cfg.RunImageMirrors, []string{"gcr.io/some/run2", "gcr.io/child/run"})
+				})
+			})
+
+			when("builder.toml extends a builder image", func() {
+				var (
+					mockParentImage *mocks.MockImage
+					builderTOMLPath string
+				)
+
+				it.Before(func() {
+					mockParentImage = mocks.NewMockImage(mockController)
+					mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/parent-builder", gomock.Any()).Return(mockParentImage, nil)
+					mockParentImage.EXPECT().Found().Return(true, nil)
+					mockParentImage.EXPECT().Label("io.buildpacks.stack.id").Return("com.example.stack", nil)
+					mockParentImage.EXPECT().Label(builder.MetadataLabel).Return(`{
+  "buildpacks": [{"id": "some.bp1", "version": "1.2.3", "latest": true}, {"id": "some/bp2", "version": "1.2.4", "latest": true}],
+  "groups": [{"buildpacks": [{"id": "some.bp1", "version": "1.2.3"}]}],
+  "stack": {"runImage": {"image": "some/parent-run", "mirrors": ["gcr.io/some/parent-run"]}},
+  "lifecycle": {"version": "0.2.0", "platformApi": "0.1"}
+}`, nil).AnyTimes()
+
+					bpDir, err := filepath.Abs("testdata")
+					h.AssertNil(t, err)
+					file, err := ioutil.TempFile("", "builder.toml")
+					h.AssertNil(t, err)
+					builderTOMLPath = file.Name()
+					_, err = file.WriteString(fmt.Sprintf(`extends = "some/parent-builder"
+
+[[buildpacks]]
+id = "some/bp2"
+uri = "%s/some-latest-path-2"
+latest = true
+`, bpDir))
+					h.AssertNil(t, err)
+					file.Close()
+				})
+
+				it.After(func() {
+					os.Remove(builderTOMLPath)
+				})
+
+				it("uses the parent image as the base image and inherits its buildpacks, groups and stack", func() {
+					mockParentImage.EXPECT().Rename("some/image")
+
+					cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+						RepoName:        "some/image",
+						BuilderTomlPath: builderTOMLPath,
+					})
+					h.AssertNil(t, err)
+					h.AssertSameInstance(t, cfg.Repo, mockParentImage)
+					h.AssertEq(t, len(cfg.Buildpacks), 1)
+					h.AssertEq(t, cfg.InheritedBuildpacks, []builder.BuildpackMetadata{
+						{ID: "some.bp1", Version: "1.2.3", Latest: true},
+						{ID: "some/bp2", Version: "1.2.4", Latest: false},
+					})
+					h.AssertEq(t, cfg.Groups, []lifecycle.BuildpackGroup{{Buildpacks: []*lifecycle.Buildpack{{ID: "some.bp1", Version: "1.2.3"}}}})
+					h.AssertEq(t, cfg.StackID, "com.example.stack")
+					h.AssertEq(t, cfg.RunImage, "some/parent-run")
+					h.AssertEq(t, cfg.RunImageMirrors, []string{"gcr.io/some/parent-run"})
+					h.AssertEq(t, cfg.Lifecycle.Version, "0.2.0")
+				})
+			})
+
+			it("validates the lifecycle platform API is supported", func() {
+				file, err := ioutil.TempFile("", "builder.toml")
+				h.AssertNil(t, err)
+
+				_, err = file.WriteString(`
+[stack]
+id = "some.id"
+build-image = "packs/build:v3alpha2"
+run-image = "packs/run:v3alpha2"
+
+[lifecycle]
+version = "9.9.9"
+platform-api = "9.9"
+`)
+				h.AssertNil(t, err)
+				file.Close()
+
+				_, err = factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: file.Name(),
+				})
+				h.AssertError(t, err, "lifecycle.platform-api '9.9' is not supported (supported platform APIs: 0.1)")
+			})
+		})
+
+		when("#Create checks the stacks of buildpacks", func() {
+			var (
+				mockImage     *mocks.MockImage
+				builderConfig pack.BuilderConfig
+			)
+
+			it.Before(func() {
+				mockImage = mocks.NewMockImage(mockController)
+				mockImage.EXPECT().AddLayer(gomock.Any()).AnyTimes()
+				mockImage.EXPECT().Name().Return("some/build").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").DoAndReturn(func(string) (string, error) {
+					return builderConfig.StackID, nil
+				}).AnyTimes()
+				builderConfig = pack.BuilderConfig{
+					Repo:     mockImage,
+					StackID:  "some.stack.id",
+					RunImage: "myorg/run",
+				}
+			})
+
+			it("rejects a build image of another stack", func() {
+				mockImage = mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Name().Return("some/build").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("other.stack.id", nil)
+				builderConfig.Repo = mockImage
+
+				err := factory.Create(builderConfig)
+				h.AssertError(t, err, "build image 'some/build' has stack 'other.stack.id', but 'stack.id' is 'some.stack.id'")
+			})
+
+			it("warns about a build image without a stack", func() {
+				mockImage = mocks.NewMockImage(mockController)
+				mockImage.EXPECT().AddLayer(gomock.Any()).AnyTimes()
+				mockImage.EXPECT().Name().Return("some/build").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("", nil)
+				mockImage.EXPECT().SetLabel(gomock.Any(), gomock.Any())
+				mockImage.EXPECT().SetEnv(gomock.Any(), gomock.Any())
+				mockImage.EXPECT().Save()
+				builderConfig.Repo = mockImage
+
+				h.AssertNil(t, factory.Create(builderConfig))
+				h.AssertContains(t, outBuf.String(), "Warning: build image 'some/build' is missing label 'io.buildpacks.stack.id'")
+			})
+
+			it("rejects a buildpack that does not support the builder's stack", func() {
+				builderConfig.StackID = "other.stack.id"
+				builderConfig.Buildpacks = []buildpack.Buildpack{{ID: "some-buildpack-id", Dir: "testdata/buildpack"}}
+
+				err := factory.Create(builderConfig)
+				h.AssertError(t, err, "buildpack 'some-buildpack-id' does not support stack 'other.stack.id', it only supports: some.stack.id")
+			})
+
+			it("warns about a buildpack that does not declare its stacks", func() {
+				mockImage.EXPECT().SetLabel(gomock.Any(), gomock.Any())
+				mockImage.EXPECT().SetEnv(gomock.Any(), gomock.Any())
+				mockImage.EXPECT().Save()
+
+				bpDir, err := ioutil.TempDir("", "create-builder-buildpack")
+				h.AssertNil(t, err)
+				defer os.RemoveAll(bpDir)
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpDir, "buildpack.toml"), []byte("[buildpack]\nid = \"some-buildpack-id\"\nversion = \"1.0\"\n"), 0644))
+				builderConfig.Buildpacks = []buildpack.Buildpack{{ID: "some-buildpack-id", Dir: bpDir}}
+
+				h.AssertNil(t, factory.Create(builderConfig))
+				h.AssertContains(t, outBuf.String(), "Warning: buildpack 'some-buildpack-id' does not declare the stacks it supports")
+			})
 		})
 
 		when("#Create", func() {
@@ -234,6 +531,7 @@ build-image = "packs/build:v3alpha2"
 					labels[labelName] = labelValue
 				})
 				mockImage.EXPECT().SetEnv(gomock.Any(), gomock.Any()).Do(func(key, val string) { env[key] = val }).AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
 				mockImage.EXPECT().Save()
 
 				builderConfig = pack.BuilderConfig{
@@ -241,6 +539,7 @@ build-image = "packs/build:v3alpha2"
 					Buildpacks:      []buildpack.Buildpack{},
 					Groups:          []lifecycle.BuildpackGroup{},
 					BuilderDir:      "",
+					StackID:         "some.stack.id",
 					RunImage:        "myorg/run",
 					RunImageMirrors: []string{"gcr.io/myorg/run"},
 				}
@@ -250,7 +549,16 @@ build-image = "packs/build:v3alpha2"
 				h.AssertNil(t, factory.Create(builderConfig))
 				h.AssertEq(t,
 					labels["io.buildpacks.builder.metadata"],
-					`{"buildpacks":[],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+					`{"buildpacks":[],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}},"lifecycle":{"platformApi":"0.1"}}`,
+				)
+			})
+
+			it("records the lifecycle version and platform API in the builder label", func() {
+				builderConfig.Lifecycle = builder.LifecycleMetadata{Version: "1.2.3", PlatformAPI: "0.1"}
+				h.AssertNil(t, factory.Create(builderConfig))
+				h.AssertContains(t,
+					labels["io.buildpacks.builder.metadata"],
+					`"lifecycle":{"version":"1.2.3","platformApi":"0.1"}`,
 				)
 			})
 
@@ -282,11 +590,30 @@ build-image = "packs/build:v3alpha2"
 					h.AssertNil(t, factory.Create(builderConfig))
 					h.AssertEq(t,
 						labels["io.buildpacks.builder.metadata"],
-						`{"buildpacks":[{"id":"some-buildpack-id","version":"some-buildpack-version","latest":true}],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+						`{"buildpacks":[{"id":"some-buildpack-id","version":"some-buildpack-version","latest":true,"stacks":["some.stack.id"]}],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}},"lifecycle":{"platformApi":"0.1"}}`,
 					)
 				})
 			})
 
+			it("records the description, maintainer and build env in the builder label", func() {
+				builderConfig.Description = "Some description"
+				builderConfig.Maintainer = "Some maintainer"
+				builderConfig.BuildEnv = map[string]string{"SOME_VAR": "some-value"}
+
+				h.AssertNil(t, factory.Create(builderConfig))
+				h.AssertContains(t, labels["io.buildpacks.builder.metadata"], `"description":"Some description","maintainer":"Some maintainer","buildEnv":{"SOME_VAR":"some-value"}`)
+			})
+
+			when("builder config inherits buildpacks from a parent builder image", func() {
+				it("records the inherited buildpacks in the builder label without adding layers for them", func() {
+					builderConfig.InheritedBuildpacks = []builder.BuildpackMetadata{{ID: "parent.bp", Version: "1.0.0", Latest: true}}
+
+					h.AssertNil(t, factory.Create(builderConfig))
+					h.AssertEq(t, len(savedLayers), 3)
+					h.AssertContains(t, labels["io.buildpacks.builder.metadata"], `"buildpacks":[{"id":"parent.bp","version":"1.0.0","latest":true}]`)
+				})
+			})
+
 			when("builder config contains groups", func() {
 				it.Before(func() {
 					builderConfig.Groups = []lifecycle.BuildpackGroup{{Buildpacks: []*lifecycle.Buildpack{{ID: "bpId", Version: "bpVersion"}}}}
@@ -306,7 +633,7 @@ build-image = "packs/build:v3alpha2"
 					h.AssertNil(t, factory.Create(builderConfig))
 					h.AssertEq(t,
 						labels["io.buildpacks.builder.metadata"],
-						`{"buildpacks":[],"groups":[{"buildpacks":[{"id":"bpId","version":"bpVersion","latest":false}]}],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+						`{"buildpacks":[],"groups":[{"buildpacks":[{"id":"bpId","version":"bpVersion","latest":false}]}],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}},"lifecycle":{"platformApi":"0.1"}}`,
 					)
 				})
 			})
@@ -341,22 +668,28 @@ func checkGroups(t *testing.T, groups []lifecycle.BuildpackGroup) {
 func checkBuildpacks(t *testing.T, buildpacks []buildpack.Buildpack) {
 	if diff := cmp.Diff(buildpacks, []buildpack.Buildpack{
 		{
-			ID:     "some.bp1",
-			Dir:    filepath.Join("testdata", "some-path-1"),
-			URI:    "some-path-1",
-			Latest: false,
+			ID:      "some.bp1",
+			Dir:     filepath.Join("testdata", "some-path-1"),
+			URI:     "some-path-1",
+			Version: "1.2.3",
+			Stacks:  []string{"com.example.stack"},
+			Latest:  false,
 		},
 		{
-			ID:     "some/bp2",
-			Dir:    filepath.Join("testdata", "some-path-2"),
-			URI:    "some-path-2",
-			Latest: false,
+			ID:      "some/bp2",
+			Dir:     filepath.Join("testdata", "some-path-2"),
+			URI:     "some-path-2",
+			Version: "1.2.4",
+			Stacks:  []string{"com.example.stack"},
+			Latest:  false,
 		},
 		{
-			ID:     "some/bp2",
-			Dir:    filepath.Join("testdata", "some-latest-path-2"),
-			URI:    "some-latest-path-2",
-			Latest: true,
+			ID:      "some/bp2",
+			Dir:     filepath.Join("testdata", "some-latest-path-2"),
+			URI:     "some-latest-path-2",
+			Version: "1.2.5",
+			Stacks:  []string{"com.example.stack"},
+			Latest:  true,
 		},
 	}); diff != "" {
 		t.Fatalf("config has incorrect buildpacks, %s", diff)
@@ -0,0 +1,147 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	lcimg "github.com/buildpack/lifecycle/image"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/stack"
+	"github.com/buildpack/pack/style"
+)
+
+type StackCreator struct {
+	Logger  *logging.Logger
+	Docker  Docker
+	Fetcher Fetcher
+}
+
+type CreateStackFlags struct {
+	DescriptorPath string
+	Publish        bool
+	NoPull         bool
+}
+
+// Create builds the build and run images described by a stack.toml in the daemon, and pushes them to their registry
+// when publishing. A base image that already belongs to another stack is rejected.
+func (s *StackCreator) Create(ctx context.Context, flags CreateStackFlags) (stack.Descriptor, error) {
+	desc, err := stack.ReadDescriptor(flags.DescriptorPath)
+	if err != nil {
+		return stack.Descriptor{}, err
+	}
+
+	for _, img := range []stack.Image{desc.Build, desc.Run} {
+		if err := s.checkBaseImage(ctx, desc, img, flags.NoPull); err != nil {
+			return stack.Descriptor{}, err
+		}
+	}
+	for _, img := range []stack.Image{desc.Build, desc.Run} {
+		if err := s.buildImage(ctx, desc, img); err != nil {
+			return stack.Descriptor{}, err
+		}
+	}
+	if err := s.checkStackImages(desc); err != nil {
+		return stack.Descriptor{}, err
+	}
+
+	if flags.Publish {
+		for _, img := range []stack.Image{desc.Build, desc.Run} {
+			s.Logger.Verbose("Pushing image %s", style.Symbol(img.Image))
+			if err := s.Docker.PushImage(ctx, img.Image, s.Logger.RawVerboseWriter()); err != nil {
+				return stack.Descriptor{}, errors.Wrapf(err, "failed to push image %s", style.Symbol(img.Image))
+			}
+		}
+	}
+	return desc, nil
+}
+
+func (s *StackCreator) checkBaseImage(ctx context.Context, desc stack.Descriptor, img stack.Image, noPull bool) error {
+	name := desc.BaseImageFor(img)
+	var (
+		base lcimg.Image
+		err  error
+	)
+	if noPull {
+		base, err = s.Fetcher.FetchLocalImage(name)
+	} else {
+		base, err = s.Fetcher.FetchUpdatedLocalImage(ctx, name, s.Logger.RawVerboseWriter())
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch base image %s", style.Symbol(name))
+	}
+	if found, err := base.Found(); err != nil {
+		return errors.Wrapf(err, "failed to find base image %s", style.Symbol(name))
+	} else if !found {
+		return fmt.Errorf("base image %s not found", style.Symbol(name))
+	}
+
+	stackID, err := base.Label(stack.IDLabel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read stack of base image %s", style.Symbol(name))
+	}
+	if stackID != "" && stackID != desc.ID {
+		return fmt.Errorf("base image %s belongs to stack %s, not %s", style.Symbol(name), style.Symbol(stackID), style.Symbol(desc.ID))
+	}
+	return nil
+}
+
+func (s *StackCreator) buildImage(ctx context.Context, desc stack.Descriptor, img stack.Image) error {
+	s.Logger.Verbose("Building image %s from %s", style.Symbol(img.Image), style.Symbol(desc.BaseImageFor(img)))
+	dockerfile, err := desc.Dockerfile(img)
+	if err != nil {
+		return err
+	}
+	buildContext, err := archive.CreateSingleFileTarReader("Dockerfile", dockerfile)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.Docker.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        []string{img.Image},
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to build image %s", style.Symbol(img.Image))
+	}
+	defer res.Body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(res.Body, s.Logger.RawVerboseWriter(), 0, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to build image %s", style.Symbol(img.Image))
+	}
+	return nil
+}
+
+// checkStackImages checks that the build and run images share the stack ID and user that pack will read from them
+func (s *StackCreator) checkStackImages(desc stack.Descriptor) error {
+	for _, name := range []string{desc.Build.Image, desc.Run.Image} {
+		img, err := s.Fetcher.FetchLocalImage(name)
+		if err != nil {
+			return err
+		}
+		stackID, err := img.Label(stack.IDLabel)
+		if err != nil {
+			return err
+		}
+		if stackID != desc.ID {
+			return fmt.Errorf("image %s has stack %s, expected %s", style.Symbol(name), style.Symbol(stackID), style.Symbol(desc.ID))
+		}
+		for key, id := range map[string]int{"CNB_USER_ID": desc.User.UID, "CNB_GROUP_ID": desc.User.GID} {
+			expected := strconv.Itoa(id)
+			value, err := img.Env(key)
+			if err != nil {
+				return err
+			}
+			if value != expected {
+				return fmt.Errorf("image %s has %s %s, expected %s", style.Symbol(name), key, style.Symbol(value), style.Symbol(expected))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/docker/docker/api/types"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestCreateStack(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "CreateStack", testCreateStack, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCreateStack(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockDocker     *mocks.MockDocker
+		mockFetcher    *mocks.MockFetcher
+		subject        *pack.StackCreator
+		flags          pack.CreateStackFlags
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = mocks.NewMockDocker(mockController)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+
+		var outBuf bytes.Buffer
+		subject = &pack.StackCreator{
+			Logger:  logging.NewLogger(&outBuf, &outBuf, false, false),
+			Docker:  mockDocker,
+			Fetcher: mockFetcher,
+		}
+		flags = pack.CreateStackFlags{DescriptorPath: filepath.Join("testdata", "stack.toml"), NoPull: true}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	stackImage := func(name, stackID string) *imgtest.FakeImage {
+		img := imgtest.NewFakeImage(t, name, "", "")
+		h.AssertNil(t, img.SetLabel("io.buildpacks.stack.id", stackID))
+		h.AssertNil(t, img.SetEnv("CNB_USER_ID", "1000"))
+		h.AssertNil(t, img.SetEnv("CNB_GROUP_ID", "1000"))
+		return img
+	}
+
+	buildResponse := func(body string) types.ImageBuildResponse {
+		return types.ImageBuildResponse{Body: ioutil.NopCloser(strings.NewReader(body))}
+	}
+
+	expectBaseImages := func() {
+		mockFetcher.EXPECT().FetchLocalImage("some/base").Return(imgtest.NewFakeImage(t, "some/base", "", ""), nil)
+		mockFetcher.EXPECT().FetchLocalImage("some/run-base").Return(imgtest.NewFakeImage(t, "some/run-base", "", ""), nil)
+	}
+
+	when("#Create", func() {
+		it("builds the build and run images", func() {
+			expectBaseImages()
+			var tags [][]string
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+				DoAndReturn(func(_ context.Context, _ interface{}, opts types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+					tags = append(tags, opts.Tags)
+					return buildResponse(`{"stream": "Successfully built"}`), nil
+				})
+			mockFetcher.EXPECT().FetchLocalImage("some/build").Return(stackImage("some/build", "com.example.stacks.some"), nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(stackImage("some/run", "com.example.stacks.some"), nil)
+
+			desc, err := subject.Create(context.TODO(), flags)
+			h.AssertNil(t, err)
+			h.AssertEq(t, desc.ID, "com.example.stacks.some")
+			h.AssertEq(t, tags, [][]string{{"some/build"}, {"some/run"}})
+		})
+
+		it("pushes the images when publishing", func() {
+			expectBaseImages()
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+				DoAndReturn(func(context.Context, interface{}, types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+					return buildResponse(`{"stream": "Successfully built"}`), nil
+				})
+			mockFetcher.EXPECT().FetchLocalImage("some/build").Return(stackImage("some/build", "com.example.stacks.some"), nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(stackImage("some/run", "com.example.stacks.some"), nil)
+			mockDocker.EXPECT().PushImage(gomock.Any(), "some/build", gomock.Any())
+			mockDocker.EXPECT().PushImage(gomock.Any(), "some/run", gomock.Any())
+
+			flags.Publish = true
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertNil(t, err)
+		})
+
+		it("rejects a base image of another stack", func() {
+			mockFetcher.EXPECT().FetchLocalImage("some/base").Return(stackImage("some/base", "other.stack"), nil)
+
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertError(t, err, "base image 'some/base' belongs to stack 'other.stack', not 'com.example.stacks.some'")
+		})
+
+		it("fails when a base image is not found", func() {
+			mockFetcher.EXPECT().FetchLocalImage("some/base").Return(imgtest.NewFakeImage(t, "some/base", "", ""), nil)
+			missing := mocks.NewMockImage(mockController)
+			missing.EXPECT().Found().Return(false, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run-base").Return(missing, nil)
+
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertError(t, err, "base image 'some/run-base' not found")
+		})
+
+		it("reports a failed build", func() {
+			expectBaseImages()
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(buildResponse(`{"errorDetail": {"message": "useradd: not found"}, "error": "useradd: not found"}`), nil)
+
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertError(t, err, "failed to build image 'some/build': useradd: not found")
+		})
+
+		it("fails when the images do not share the stack", func() {
+			expectBaseImages()
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+				DoAndReturn(func(context.Context, interface{}, types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+					return buildResponse(`{"stream": "Successfully built"}`), nil
+				})
+			mockFetcher.EXPECT().FetchLocalImage("some/build").Return(stackImage("some/build", "com.example.stacks.some"), nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(stackImage("some/run", "other.stack"), nil)
+
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertError(t, err, "image 'some/run' has stack 'other.stack', expected 'com.example.stacks.some'")
+		})
+
+		it("reports a failed push", func() {
+			expectBaseImages()
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+				DoAndReturn(func(context.Context, interface{}, types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+					return buildResponse(`{"stream": "Successfully built"}`), nil
+				})
+			mockFetcher.EXPECT().FetchLocalImage("some/build").Return(stackImage("some/build", "com.example.stacks.some"), nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(stackImage("some/run", "com.example.stacks.some"), nil)
+			mockDocker.EXPECT().PushImage(gomock.Any(), "some/build", gomock.Any()).Return(errors.New("denied"))
+
+			flags.Publish = true
+			_, err := subject.Create(context.TODO(), flags)
+			h.AssertError(t, err, "failed to push image 'some/build': denied")
+		})
+	})
+}
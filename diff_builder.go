@@ -0,0 +1,175 @@
+package pack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	BuildpackAdded   = "added"
+	BuildpackRemoved = "removed"
+	BuildpackChanged = "changed"
+)
+
+// BuilderDiff describes how a builder differs from another. Fields that did not change are left empty.
+type BuilderDiff struct {
+	Old              string            `json:"old"`
+	New              string            `json:"new"`
+	Stack            *ValueChange      `json:"stack,omitempty"`
+	RunImage         *ValueChange      `json:"runImage,omitempty"`
+	RunImageMirrors  *ListChange       `json:"runImageMirrors,omitempty"`
+	LifecycleVersion *ValueChange      `json:"lifecycleVersion,omitempty"`
+	PlatformAPI      *ValueChange      `json:"platformApi,omitempty"`
+	Buildpacks       []BuildpackChange `json:"buildpacks,omitempty"`
+	Order            *OrderChange      `json:"order,omitempty"`
+}
+
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type ListChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// BuildpackChange describes a buildpack ID that was added, removed, or whose versions changed
+type BuildpackChange struct {
+	ID          string   `json:"id"`
+	Change      string   `json:"change"`
+	OldVersions []string `json:"oldVersions,omitempty"`
+	NewVersions []string `json:"newVersions,omitempty"`
+}
+
+// OrderChange holds both detection orders, with each buildpack as <id>@<version>
+type OrderChange struct {
+	Old [][]string `json:"old"`
+	New [][]string `json:"new"`
+}
+
+func (d *BuilderDiff) HasChanges() bool {
+	return d.Stack != nil || d.RunImage != nil || d.RunImageMirrors != nil || d.LifecycleVersion != nil ||
+		d.PlatformAPI != nil || len(d.Buildpacks) > 0 || d.Order != nil
+}
+
+// DiffBuilders compares two builders, each read from the daemon or the registry
+func (c *Client) DiffBuilders(oldName, newName string, oldDaemon, newDaemon bool) (*BuilderDiff, error) {
+	oldInfo, err := c.inspectExistingBuilder(oldName, oldDaemon)
+	if err != nil {
+		return nil, err
+	}
+	newInfo, err := c.inspectExistingBuilder(newName, newDaemon)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffBuilderInfo(oldInfo, newInfo)
+	diff.Old, diff.New = oldName, newName
+	return &diff, nil
+}
+
+func (c *Client) inspectExistingBuilder(name string, daemon bool) (*BuilderInfo, error) {
+	info, err := c.InspectBuilder(name, daemon)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("builder image %s not found", style.Symbol(name))
+	}
+	return info, nil
+}
+
+// DiffBuilderInfo compares two inspected builders
+func DiffBuilderInfo(oldInfo, newInfo *BuilderInfo) BuilderDiff {
+	var diff BuilderDiff
+	diff.Stack = valueChange(oldInfo.Stack, newInfo.Stack)
+	diff.RunImage = valueChange(oldInfo.RunImage, newInfo.RunImage)
+	diff.LifecycleVersion = valueChange(oldInfo.LifecycleVersion, newInfo.LifecycleVersion)
+	diff.PlatformAPI = valueChange(oldInfo.PlatformAPI, newInfo.PlatformAPI)
+
+	mirrors := ListChange{
+		Added:   missingFrom(newInfo.RunImageMirrors, oldInfo.RunImageMirrors),
+		Removed: missingFrom(oldInfo.RunImageMirrors, newInfo.RunImageMirrors),
+	}
+	if len(mirrors.Added) > 0 || len(mirrors.Removed) > 0 {
+		diff.RunImageMirrors = &mirrors
+	}
+
+	oldVersions, newVersions := buildpackVersions(oldInfo.Buildpacks), buildpackVersions(newInfo.Buildpacks)
+	var ids []string
+	for id := range oldVersions {
+		ids = append(ids, id)
+	}
+	for id := range newVersions {
+		if _, ok := oldVersions[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		change := BuildpackChange{ID: id, OldVersions: oldVersions[id], NewVersions: newVersions[id]}
+		switch {
+		case change.OldVersions == nil:
+			change.Change = BuildpackAdded
+		case change.NewVersions == nil:
+			change.Change = BuildpackRemoved
+		case strings.Join(change.OldVersions, ",") != strings.Join(change.NewVersions, ","):
+			change.Change = BuildpackChanged
+		default:
+			continue
+		}
+		diff.Buildpacks = append(diff.Buildpacks, change)
+	}
+
+	oldOrder, newOrder := orderRefs(oldInfo.Groups), orderRefs(newInfo.Groups)
+	if fmt.Sprint(oldOrder) != fmt.Sprint(newOrder) {
+		diff.Order = &OrderChange{Old: oldOrder, New: newOrder}
+	}
+	return diff
+}
+
+func valueChange(oldValue, newValue string) *ValueChange {
+	if oldValue == newValue {
+		return nil
+	}
+	return &ValueChange{Old: oldValue, New: newValue}
+}
+
+// missingFrom returns the values that are not in other
+func missingFrom(values, other []string) []string {
+	var missing []string
+	for _, v := range values {
+		if !containsString(other, v) {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+func buildpackVersions(buildpacks []BuildpackInfo) map[string][]string {
+	versions := map[string][]string{}
+	for _, bp := range buildpacks {
+		versions[bp.ID] = append(versions[bp.ID], bp.Version)
+	}
+	for _, v := range versions {
+		buildpack.SortVersions(v)
+	}
+	return versions
+}
+
+func orderRefs(groups [][]BuildpackInfo) [][]string {
+	refs := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		var groupRefs []string
+		for _, bp := range group {
+			groupRefs = append(groupRefs, bp.ID+"@"+bp.Version)
+		}
+		refs = append(refs, groupRefs)
+	}
+	return refs
+}
@@ -0,0 +1,141 @@
+package pack_test
+
+import (
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestDiffBuilder(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "DiffBuilder", testDiffBuilder, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDiffBuilder(t *testing.T, when spec.G, it spec.S) {
+	when("#DiffBuilderInfo", func() {
+		var oldInfo *pack.BuilderInfo
+
+		it.Before(func() {
+			oldInfo = &pack.BuilderInfo{
+				Stack:            "some.stack.id",
+				RunImage:         "some/run",
+				RunImageMirrors:  []string{"gcr.io/some/run", "quay.io/some/run"},
+				LifecycleVersion: "0.1.0",
+				PlatformAPI:      "0.1",
+				Buildpacks: []pack.BuildpackInfo{
+					{ID: "some.bp", Version: "1.0.0"},
+					{ID: "removed.bp", Version: "0.1.0"},
+					{ID: "bumped.bp", Version: "2.0.0"},
+				},
+				Groups: [][]pack.BuildpackInfo{{{ID: "some.bp", Version: "1.0.0"}, {ID: "bumped.bp", Version: "2.0.0"}}},
+			}
+		})
+
+		it("finds no changes between identical builders", func() {
+			diff := pack.DiffBuilderInfo(oldInfo, oldInfo)
+			h.AssertEq(t, diff.HasChanges(), false)
+		})
+
+		it("reports every change", func() {
+			newInfo := &pack.BuilderInfo{
+				Stack:            "other.stack.id",
+				RunImage:         "some/run",
+				RunImageMirrors:  []string{"gcr.io/some/run", "docker.io/some/run"},
+				LifecycleVersion: "0.2.0",
+				PlatformAPI:      "0.1",
+				Buildpacks: []pack.BuildpackInfo{
+					{ID: "some.bp", Version: "1.0.0"},
+					{ID: "bumped.bp", Version: "2.1.0"},
+					{ID: "added.bp", Version: "3.0.0"},
+				},
+				Groups: [][]pack.BuildpackInfo{{{ID: "bumped.bp", Version: "2.1.0"}, {ID: "some.bp", Version: "1.0.0"}}},
+			}
+
+			diff := pack.DiffBuilderInfo(oldInfo, newInfo)
+			h.AssertEq(t, diff.HasChanges(), true)
+			h.AssertEq(t, diff, pack.BuilderDiff{
+				Stack:            &pack.ValueChange{Old: "some.stack.id", New: "other.stack.id"},
+				RunImageMirrors:  &pack.ListChange{Added: []string{"docker.io/some/run"}, Removed: []string{"quay.io/some/run"}},
+				LifecycleVersion: &pack.ValueChange{Old: "0.1.0", New: "0.2.0"},
+				Buildpacks: []pack.BuildpackChange{
+					{ID: "added.bp", Change: pack.BuildpackAdded, NewVersions: []string{"3.0.0"}},
+					{ID: "bumped.bp", Change: pack.BuildpackChanged, OldVersions: []string{"2.0.0"}, NewVersions: []string{"2.1.0"}},
+					{ID: "removed.bp", Change: pack.BuildpackRemoved, OldVersions: []string{"0.1.0"}},
+				},
+				Order: &pack.OrderChange{
+					Old: [][]string{{"some.bp@1.0.0", "bumped.bp@2.0.0"}},
+					New: [][]string{{"bumped.bp@2.1.0", "some.bp@1.0.0"}},
+				},
+			})
+		})
+	})
+
+	when("#DiffBuilders", func() {
+		var (
+			client         *pack.Client
+			mockFetcher    *mocks.MockFetcher
+			mockController *gomock.Controller
+		)
+
+		it.Before(func() {
+			mockController = gomock.NewController(t)
+			mockFetcher = mocks.NewMockFetcher(mockController)
+			client = pack.NewClient(&config.Config{}, mockFetcher)
+		})
+
+		it.After(func() {
+			mockController.Finish()
+		})
+
+		newBuilder := func(name, metadata string) *imgtest.FakeImage {
+			img := imgtest.NewFakeImage(t, name, "", "")
+			h.AssertNil(t, img.SetLabel("io.buildpacks.stack.id", "some.stack.id"))
+			h.AssertNil(t, img.SetLabel("io.buildpacks.builder.metadata", metadata))
+			return img
+		}
+
+		it("compares two builders from the registry", func() {
+			mockFetcher.EXPECT().FetchRemoteImage("some/builder:old").Return(newBuilder("some/builder:old", `{"buildpacks": [{"id": "some.bp", "version": "1.0.0"}], "stack": {"runImage": {"image": "some/run"}}}`), nil)
+			mockFetcher.EXPECT().FetchRemoteImage("some/builder:new").Return(newBuilder("some/builder:new", `{"buildpacks": [{"id": "some.bp", "version": "1.1.0"}], "stack": {"runImage": {"image": "some/run"}}}`), nil)
+
+			diff, err := client.DiffBuilders("some/builder:old", "some/builder:new", false, false)
+			h.AssertNil(t, err)
+			h.AssertEq(t, diff, &pack.BuilderDiff{
+				Old: "some/builder:old",
+				New: "some/builder:new",
+				Buildpacks: []pack.BuildpackChange{
+					{ID: "some.bp", Change: pack.BuildpackChanged, OldVersions: []string{"1.0.0"}, NewVersions: []string{"1.1.0"}},
+				},
+			})
+		})
+
+		it("compares a builder in the daemon to one in the registry", func() {
+			mockFetcher.EXPECT().FetchLocalImage("some/builder:local").Return(newBuilder("some/builder:local", `{"buildpacks": [{"id": "some.bp", "version": "1.10.0"}, {"id": "some.bp", "version": "1.9.0"}], "stack": {"runImage": {"image": "some/run"}}}`), nil)
+			mockFetcher.EXPECT().FetchRemoteImage("some/builder:remote").Return(newBuilder("some/builder:remote", `{"buildpacks": [{"id": "some.bp", "version": "1.9.0"}], "stack": {"runImage": {"image": "some/run"}}}`), nil)
+
+			diff, err := client.DiffBuilders("some/builder:local", "some/builder:remote", true, false)
+			h.AssertNil(t, err)
+			h.AssertEq(t, diff.Buildpacks, []pack.BuildpackChange{
+				{ID: "some.bp", Change: pack.BuildpackChanged, OldVersions: []string{"1.9.0", "1.10.0"}, NewVersions: []string{"1.9.0"}},
+			})
+		})
+
+		it("fails when a builder is not found", func() {
+			missing := mocks.NewMockImage(mockController)
+			missing.EXPECT().Found().Return(false, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/builder:missing").Return(missing, nil)
+
+			_, err := client.DiffBuilders("some/builder:missing", "some/builder:old", true, true)
+			h.AssertError(t, err, "builder image 'some/builder:missing' not found")
+		})
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/buildpack/lifecycle/image/auth"
 	dockertypes "github.com/docker/docker/api/types"
@@ -24,6 +25,19 @@ type Client struct {
 	*dockercli.Client
 }
 
+// ExitError is returned by RunContainer and ExecContainer when the container or command exits with a non-zero status code
+type ExitError struct {
+	StatusCode int64
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("failed with status code: %d", e.StatusCode)
+}
+
+func (e *ExitError) ExitCode() int {
+	return int(e.StatusCode)
+}
+
 func New() (*Client, error) {
 	cli, err := dockercli.NewClientWithOpts(dockercli.FromEnv, dockercli.WithVersion("1.38"))
 	if err != nil {
@@ -56,7 +70,7 @@ func (d *Client) RunContainer(ctx context.Context, id string, stdout io.Writer,
 	select {
 	case body := <-bodyChan:
 		if body.StatusCode != 0 {
-			return fmt.Errorf("failed with status code: %d", body.StatusCode)
+			return &ExitError{StatusCode: body.StatusCode}
 		}
 	case err := <-errChan:
 		return err
@@ -64,6 +78,42 @@ func (d *Client) RunContainer(ctx context.Context, id string, stdout io.Writer,
 	return <-copyErr
 }
 
+// ExecContainer runs a command in the running container id and waits for it to exit
+func (d *Client) ExecContainer(ctx context.Context, id string, config dockertypes.ExecConfig, stdout io.Writer, stderr io.Writer) error {
+	config.AttachStdout = true
+	config.AttachStderr = true
+	exec, err := d.ContainerExecCreate(ctx, id, config)
+	if err != nil {
+		return errors.Wrap(err, "container exec create")
+	}
+	resp, err := d.ContainerExecAttach(ctx, exec.ID, dockertypes.ExecStartCheck{})
+	if err != nil {
+		return errors.Wrap(err, "container exec attach")
+	}
+	defer resp.Close()
+	if _, err := stdcopy.StdCopy(stdout, stderr, resp.Reader); err != nil {
+		return err
+	}
+
+	for {
+		inspect, err := d.ContainerExecInspect(ctx, exec.ID)
+		if err != nil {
+			return errors.Wrap(err, "container exec inspect")
+		}
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return &ExitError{StatusCode: int64(inspect.ExitCode)}
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 func (d *Client) PullImage(ctx context.Context, imageID string, stdout io.Writer) error {
 	regAuth, err := d.registryAuth(imageID)
 	if err != nil {
@@ -92,6 +142,28 @@ func (d *Client) PullImage(ctx context.Context, imageID string, stdout io.Writer
 	return rc.Close()
 }
 
+func (d *Client) PushImage(ctx context.Context, imageID string, stdout io.Writer) error {
+	regAuth, err := d.registryAuth(imageID)
+	if err != nil {
+		return errors.Wrap(err, "auth for docker push")
+	}
+
+	rc, err := d.Client.ImagePush(ctx, imageID, dockertypes.ImagePushOptions{
+		RegistryAuth: regAuth,
+	})
+	if err != nil {
+		return err
+	}
+
+	termFd, isTerm := term.GetFdInfo(stdout)
+	err = jsonmessage.DisplayJSONMessagesStream(rc, &colorizedWriter{stdout}, termFd, isTerm, nil)
+	if err != nil {
+		return err
+	}
+
+	return rc.Close()
+}
+
 func (d *Client) registryAuth(ref string) (string, error) {
 	var regAuth string
 	_, a, err := auth.ReferenceForRepoName(authn.DefaultKeychain, ref)
@@ -0,0 +1,210 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/lifecycle"
+	lcimg "github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/style"
+)
+
+// readBuilderTOML reads a builder.toml and merges it onto the builder.toml it extends, if any. When the parents end
+// with a builder image, Extends of the result names that image.
+func readBuilderTOML(path string, seen map[string]bool) (*builder.TOML, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if seen[absPath] {
+		return nil, fmt.Errorf("builder config %s extends itself", style.Symbol(path))
+	}
+	seen[absPath] = true
+
+	builderTOML := &builder.TOML{}
+	if _, err := toml.DecodeFile(path, builderTOML); err != nil {
+		return nil, fmt.Errorf(`failed to decode builder config from file %s: %s`, path, err)
+	}
+	if !extendsBuilderTOML(builderTOML.Extends) {
+		return builderTOML, nil
+	}
+
+	parentPath := builderTOML.Extends
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(filepath.Dir(path), parentPath)
+	}
+	parent, err := readBuilderTOML(parentPath, seen)
+	if err != nil {
+		return nil, err
+	}
+	for i, bp := range parent.Buildpacks {
+		parent.Buildpacks[i].URI = relocateBuildpackURI(bp.URI, filepath.Dir(parentPath))
+	}
+	return mergeBuilderTOML(parent, builderTOML), nil
+}
+
+// extendsBuilderTOML reports whether extends names another builder.toml rather than a builder image
+func extendsBuilderTOML(extends string) bool {
+	return strings.HasSuffix(extends, ".toml")
+}
+
+// relocateBuildpackURI makes a relative buildpack path in a parent builder.toml relative to dir, so that it still
+// refers to the same buildpack from the child
+func relocateBuildpackURI(uri, dir string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "" || filepath.IsAbs(uri) {
+		return uri
+	}
+	return filepath.Join(dir, uri)
+}
+
+// mergeBuilderTOML applies child to parent. The child's buildpacks are added to the parent's, and take over "latest"
+// for their IDs. The child's groups replace the parent's, and its run image mirrors are added to the parent's. Any other
+// stack, lifecycle or builder field the child sets overrides the parent's, and its build env is added to the parent's.
+func mergeBuilderTOML(parent, child *builder.TOML) *builder.TOML {
+	merged := *parent
+	merged.Buildpacks = append([]buildpack.Buildpack{}, child.Buildpacks...)
+	latest := latestIDs(child.Buildpacks)
+	for _, bp := range parent.Buildpacks {
+		if latest[bp.ID] {
+			bp.Latest = false
+		}
+		merged.Buildpacks = append(merged.Buildpacks, bp)
+	}
+
+	if len(child.Groups) > 0 {
+		merged.Groups = child.Groups
+	}
+
+	overrideString(&merged.Stack.ID, child.Stack.ID)
+	overrideString(&merged.Stack.BuildImage, child.Stack.BuildImage)
+	overrideString(&merged.Stack.RunImage, child.Stack.RunImage)
+	merged.Stack.RunImageMirrors = append([]string{}, parent.Stack.RunImageMirrors...)
+	for _, mirror := range child.Stack.RunImageMirrors {
+		if !containsString(merged.Stack.RunImageMirrors, mirror) {
+			merged.Stack.RunImageMirrors = append(merged.Stack.RunImageMirrors, mirror)
+		}
+	}
+
+	overrideString(&merged.Lifecycle.Version, child.Lifecycle.Version)
+	overrideString(&merged.Lifecycle.PlatformAPI, child.Lifecycle.PlatformAPI)
+	overrideString(&merged.Lifecycle.Image, child.Lifecycle.Image)
+
+	overrideString(&merged.Builder.Description, child.Builder.Description)
+	overrideString(&merged.Builder.Maintainer, child.Builder.Maintainer)
+	if len(child.Build.Env) > 0 {
+		merged.Build.Env = map[string]string{}
+		for k, v := range parent.Build.Env {
+			merged.Build.Env[k] = v
+		}
+		for k, v := range child.Build.Env {
+			merged.Build.Env[k] = v
+		}
+	}
+	return &merged
+}
+
+func overrideString(value *string, override string) {
+	if override != "" {
+		*value = override
+	}
+}
+
+func latestIDs(buildpacks []buildpack.Buildpack) map[string]bool {
+	latest := map[string]bool{}
+	for _, bp := range buildpacks {
+		if bp.Latest {
+			latest[bp.ID] = true
+		}
+	}
+	return latest
+}
+
+// extendBuilderImage fetches the builder image that builderTOML extends and merges builderTOML onto it. It returns the
+// merged builder.toml, the parent image to use as the base image, and the buildpacks the parent image already contains.
+func (f *BuilderFactory) extendBuilderImage(ctx context.Context, builderTOML *builder.TOML, publish, noPull bool) (*builder.TOML, lcimg.Image, []builder.BuildpackMetadata, error) {
+	name := builderTOML.Extends
+	img, err := f.fetchImage(ctx, name, publish, noPull)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "opening parent builder image: %s", name)
+	}
+	if found, err := img.Found(); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to find parent builder image %s", style.Symbol(name))
+	} else if !found {
+		return nil, nil, nil, fmt.Errorf("parent builder image %s not found", style.Symbol(name))
+	}
+
+	bldr := builder.NewBuilder(img, f.Config)
+	stackID, err := bldr.GetStack()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if builderTOML.Stack.ID != "" && builderTOML.Stack.ID != stackID {
+		return nil, nil, nil, fmt.Errorf("stack.id %s does not match the stack %s of parent builder %s", style.Symbol(builderTOML.Stack.ID), style.Symbol(stackID), style.Symbol(name))
+	}
+	metadata, err := bldr.GetMetadata()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	parent := &builder.TOML{
+		Groups: groupsFromMetadata(metadata.Groups),
+		Stack: builder.Stack{
+			ID:              stackID,
+			BuildImage:      name,
+			RunImage:        metadata.Stack.RunImage.Image,
+			RunImageMirrors: metadata.Stack.RunImage.Mirrors,
+		},
+		Lifecycle: builder.Lifecycle{
+			Version:     metadata.Lifecycle.Version,
+			PlatformAPI: metadata.Lifecycle.PlatformAPI,
+			Image:       metadata.Lifecycle.Image,
+		},
+		Builder: builder.Info{
+			Description: metadata.Description,
+			Maintainer:  metadata.Maintainer,
+		},
+		Build: builder.Build{Env: metadata.BuildEnv},
+	}
+	merged := mergeBuilderTOML(parent, builderTOML)
+	merged.Stack.BuildImage = name
+
+	latest := latestIDs(builderTOML.Buildpacks)
+	var inherited []builder.BuildpackMetadata
+	for _, bp := range metadata.Buildpacks {
+		if latest[bp.ID] {
+			bp.Latest = false
+		}
+		inherited = append(inherited, bp)
+	}
+	return merged, img, inherited, nil
+}
+
+func containsBuildpack(buildpacks []buildpack.Buildpack, id, version string) bool {
+	for _, bp := range buildpacks {
+		if bp.ID == id && bp.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func groupsFromMetadata(groups []builder.GroupMetadata) []lifecycle.BuildpackGroup {
+	var out []lifecycle.BuildpackGroup
+	for _, group := range groups {
+		var bps []*lifecycle.Buildpack
+		for _, bp := range group.Buildpacks {
+			bps = append(bps, &lifecycle.Buildpack{ID: bp.ID, Version: bp.Version})
+		}
+		out = append(out, lifecycle.BuildpackGroup{Buildpacks: bps})
+	}
+	return out
+}
@@ -13,12 +13,19 @@ type BuilderInfo struct {
 	LocalRunImageMirrors []string
 	Buildpacks           []BuildpackInfo
 	Groups               [][]BuildpackInfo
+	LifecycleVersion     string
+	PlatformAPI          string
+	LifecycleImage       string
+	Description          string
+	Maintainer           string
+	BuildEnv             map[string]string
 }
 
 type BuildpackInfo struct {
 	ID      string
 	Version string
 	Latest  bool
+	Stacks  []string
 }
 
 func (c *Client) InspectBuilder(name string, daemon bool) (*BuilderInfo, error) {
@@ -78,6 +85,12 @@ func (c *Client) InspectBuilder(name string, daemon bool) (*BuilderInfo, error)
 		LocalRunImageMirrors: localMirrors,
 		Buildpacks:           buildpacks,
 		Groups:               groups,
+		LifecycleVersion:     metadata.Lifecycle.Version,
+		PlatformAPI:          metadata.Lifecycle.PlatformAPI,
+		LifecycleImage:       metadata.Lifecycle.Image,
+		Description:          metadata.Description,
+		Maintainer:           metadata.Maintainer,
+		BuildEnv:             metadata.BuildEnv,
 	}, nil
 }
 
@@ -86,5 +99,6 @@ func buildpackMetadataToInfo(bp builder.BuildpackMetadata) BuildpackInfo {
 		ID:      bp.ID,
 		Version: bp.Version,
 		Latest:  bp.Latest,
+		Stacks:  bp.Stacks,
 	}
 }
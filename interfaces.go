@@ -25,6 +25,7 @@ type Docker interface {
 	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
 	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
 	PullImage(ctx context.Context, imageID string, stdout io.Writer) error
+	PushImage(ctx context.Context, imageID string, stdout io.Writer) error
 }
 
 //go:generate mockgen -package mocks -destination mocks/task.go github.com/buildpack/pack Task
@@ -41,6 +42,7 @@ type WritableStore interface {
 type ImageFactory interface {
 	NewLocal(string) (image.Image, error)
 	NewRemote(string) (image.Image, error)
+	NewEmptyLocal(string) image.Image
 }
 
 //go:generate mockgen -package mocks -destination mocks/fetcher.go github.com/buildpack/pack Fetcher
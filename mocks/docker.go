@@ -153,6 +153,18 @@ func (mr *MockDockerMockRecorder) PullImage(arg0, arg1, arg2 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullImage", reflect.TypeOf((*MockDocker)(nil).PullImage), arg0, arg1, arg2)
 }
 
+// PushImage mocks base method
+func (m *MockDocker) PushImage(arg0 context.Context, arg1 string, arg2 io.Writer) error {
+	ret := m.ctrl.Call(m, "PushImage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushImage indicates an expected call of PushImage
+func (mr *MockDockerMockRecorder) PushImage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushImage", reflect.TypeOf((*MockDocker)(nil).PushImage), arg0, arg1, arg2)
+}
+
 // RunContainer mocks base method
 func (m *MockDocker) RunContainer(arg0 context.Context, arg1 string, arg2, arg3 io.Writer) error {
 	ret := m.ctrl.Call(m, "RunContainer", arg0, arg1, arg2, arg3)
@@ -33,6 +33,18 @@ func (m *MockImageFactory) EXPECT() *MockImageFactoryMockRecorder {
 	return m.recorder
 }
 
+// NewEmptyLocal mocks base method
+func (m *MockImageFactory) NewEmptyLocal(arg0 string) image.Image {
+	ret := m.ctrl.Call(m, "NewEmptyLocal", arg0)
+	ret0, _ := ret[0].(image.Image)
+	return ret0
+}
+
+// NewEmptyLocal indicates an expected call of NewEmptyLocal
+func (mr *MockImageFactoryMockRecorder) NewEmptyLocal(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewEmptyLocal", reflect.TypeOf((*MockImageFactory)(nil).NewEmptyLocal), arg0)
+}
+
 // NewLocal mocks base method
 func (m *MockImageFactory) NewLocal(arg0 string) (image.Image, error) {
 	ret := m.ctrl.Call(m, "NewLocal", arg0)
@@ -0,0 +1,309 @@
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpack/lifecycle"
+	lcimg "github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/build"
+	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/style"
+)
+
+// whiteoutPrefix marks a file in an image layer that hides the file of the same name, without the prefix, in the
+// layers below it
+const whiteoutPrefix = ".wh."
+
+type ModifyBuilderFlags struct {
+	BuilderName string
+	// Tag is the name the modified builder is saved as, defaulting to BuilderName
+	Tag     string
+	Publish bool
+	NoPull  bool
+}
+
+type AddBuildpackFlags struct {
+	ModifyBuilderFlags
+	BuildpackURI string
+	Latest       bool
+}
+
+type RemoveBuildpackFlags struct {
+	ModifyBuilderFlags
+	// Buildpack is a reference of the form <id>[@<version>]
+	Buildpack string
+}
+
+type SetOrderFlags struct {
+	ModifyBuilderFlags
+	OrderPath string
+}
+
+// existingBuilder is a builder image being modified, with its decoded metadata
+type existingBuilder struct {
+	image    lcimg.Image
+	stackID  string
+	metadata *builder.Metadata
+	tmpDir   string
+}
+
+// AddBuildpack appends a layer for one more buildpack to an existing builder. The order of the builder is unchanged.
+func (f *BuilderFactory) AddBuildpack(ctx context.Context, flags AddBuildpackFlags) (string, error) {
+	b, err := f.openBuilder(ctx, flags.ModifyBuilderFlags)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(b.tmpDir)
+
+	bp, err := f.BuildpackFetcher.FetchBuildpack(".", buildpack.Buildpack{URI: flags.BuildpackURI, Latest: flags.Latest})
+	if err != nil {
+		return "", err
+	}
+	data, err := f.buildpackData(bp, bp.Dir)
+	if err != nil {
+		return "", err
+	}
+	bp.ID = data.BP.ID
+	for _, existing := range b.metadata.Buildpacks {
+		if existing.ID == bp.ID && existing.Version == data.BP.Version {
+			return "", fmt.Errorf("buildpack %s is already in builder %s", style.Symbol(bp.ID+"@"+existing.Version), style.Symbol(flags.BuilderName))
+		}
+	}
+
+	layerTar, err := f.buildpackLayer(b.tmpDir, &bp, ".")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate layer for buildpack %s", style.Symbol(bp.ID))
+	}
+	if err := f.checkStack(bp, b.stackID); err != nil {
+		return "", err
+	}
+	if err := b.image.AddLayer(layerTar); err != nil {
+		return "", errors.Wrap(err, "failed append buildpack layer to image")
+	}
+
+	if bp.Latest {
+		for i := range b.metadata.Buildpacks {
+			if b.metadata.Buildpacks[i].ID == bp.ID {
+				b.metadata.Buildpacks[i].Latest = false
+			}
+		}
+	}
+	b.metadata.Buildpacks = append(b.metadata.Buildpacks, builder.BuildpackMetadata{ID: bp.ID, Version: bp.Version, Latest: bp.Latest, Stacks: bp.Stacks})
+	if bp.Latest {
+		if err := b.addLatestLinks(nil); err != nil {
+			return "", err
+		}
+	}
+
+	return b.save(flags.ModifyBuilderFlags)
+}
+
+// RemoveBuildpack hides a buildpack in an existing builder. Buildpacks that the order of the builder refers to cannot be
+// removed.
+func (f *BuilderFactory) RemoveBuildpack(ctx context.Context, flags RemoveBuildpackFlags) (string, error) {
+	b, err := f.openBuilder(ctx, flags.ModifyBuilderFlags)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(b.tmpDir)
+
+	id, version := flags.Buildpack, ""
+	if i := strings.LastIndex(flags.Buildpack, "@"); i > 0 {
+		id, version = flags.Buildpack[:i], flags.Buildpack[i+1:]
+	}
+	bp, err := b.metadata.FindBuildpack(id, version)
+	if err != nil {
+		return "", err
+	}
+
+	for i, group := range b.metadata.Groups {
+		for _, entry := range group.Buildpacks {
+			if entry.ID == bp.ID && (entry.Version == bp.Version || (entry.Version == "latest" && bp.Latest)) {
+				return "", fmt.Errorf("buildpack %s is used by group %d of builder %s -- update the order with %s first", style.Symbol(bp.ID+"@"+bp.Version), i+1, style.Symbol(flags.BuilderName), style.Symbol("pack builder set-order"))
+			}
+		}
+	}
+
+	var (
+		remaining []builder.BuildpackMetadata
+		sameID    bool
+	)
+	for _, existing := range b.metadata.Buildpacks {
+		if existing.ID == bp.ID && existing.Version == bp.Version {
+			continue
+		}
+		sameID = sameID || existing.ID == bp.ID
+		remaining = append(remaining, existing)
+	}
+	b.metadata.Buildpacks = remaining
+
+	escapedID := (&buildpack.Buildpack{ID: bp.ID}).EscapedID()
+	hidden := []string{filepath.Join(escapedID, bp.Version)}
+	if bp.Latest {
+		hidden = append(hidden, filepath.Join(escapedID, "latest"))
+	}
+	if !sameID {
+		hidden = []string{escapedID}
+	} else if bp.Latest {
+		f.Logger.Info("Warning: no version of buildpack %s is marked latest anymore, so groups cannot refer to %s", style.Symbol(bp.ID), style.Symbol(bp.ID+"@latest"))
+	}
+	if err := b.addLatestLinks(hidden); err != nil {
+		return "", err
+	}
+
+	return b.save(flags.ModifyBuilderFlags)
+}
+
+// SetOrder replaces the order of an existing builder with the groups in an order.toml file. Every buildpack in the
+// groups must be in the builder already, and version constraints are resolved as in builder.toml.
+func (f *BuilderFactory) SetOrder(ctx context.Context, flags SetOrderFlags) (string, error) {
+	b, err := f.openBuilder(ctx, flags.ModifyBuilderFlags)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(b.tmpDir)
+
+	newOrder, err := build.ReadOrder(flags.OrderPath)
+	if err != nil {
+		return "", err
+	}
+	groups, err := builderGroups(newOrder)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid order file %s", style.Symbol(flags.OrderPath))
+	}
+
+	var available []buildpack.Buildpack
+	for _, bp := range b.metadata.Buildpacks {
+		available = append(available, buildpack.Buildpack{ID: bp.ID, Version: bp.Version, Latest: bp.Latest})
+	}
+	if err := resolveGroups(flags.OrderPath, groups, available); err != nil {
+		return "", err
+	}
+
+	orderTar, err := f.orderLayer(b.tmpDir, groups)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate order.toml layer")
+	}
+	if err := b.image.AddLayer(orderTar); err != nil {
+		return "", errors.Wrap(err, "failed append order.toml layer to image")
+	}
+	b.metadata.Groups = groupsMetadata(groups)
+
+	return b.save(flags.ModifyBuilderFlags)
+}
+
+// builderGroups converts an order to the groups of a builder, which can only refer to buildpacks in the builder. An
+// entry without a version refers to the version marked latest.
+func builderGroups(o build.Order) ([]lifecycle.BuildpackGroup, error) {
+	var groups []lifecycle.BuildpackGroup
+	for i, group := range o.Groups {
+		var bps []*lifecycle.Buildpack
+		for _, bp := range group.Buildpacks {
+			if bp.Path != "" {
+				return nil, fmt.Errorf("group %d has buildpack path %s, but a builder order can only refer to buildpacks in the builder", i+1, style.Symbol(bp.Path))
+			}
+			version := bp.Version
+			if version == "" {
+				version = "latest"
+			}
+			bps = append(bps, &lifecycle.Buildpack{ID: bp.ID, Version: version, Optional: bp.Optional})
+		}
+		groups = append(groups, lifecycle.BuildpackGroup{Buildpacks: bps})
+	}
+	return groups, nil
+}
+
+func (f *BuilderFactory) openBuilder(ctx context.Context, flags ModifyBuilderFlags) (*existingBuilder, error) {
+	img, err := f.fetchImage(ctx, flags.BuilderName, flags.Publish, flags.NoPull)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get builder image %s", style.Symbol(flags.BuilderName))
+	}
+	if found, err := img.Found(); err != nil {
+		return nil, errors.Wrapf(err, "failed to find builder image %s", style.Symbol(flags.BuilderName))
+	} else if !found {
+		return nil, fmt.Errorf("builder image %s not found", style.Symbol(flags.BuilderName))
+	}
+
+	bldr := builder.NewBuilder(img, f.Config)
+	stackID, err := bldr.GetStack()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := bldr.GetMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "modify-builder")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	return &existingBuilder{image: img, stackID: stackID, metadata: metadata, tmpDir: tmpDir}, nil
+}
+
+// addLatestLinks appends a layer that links each buildpack marked latest to its version, and hides the given paths
+// under /buildpacks
+func (b *existingBuilder) addLatestLinks(hidden []string) error {
+	layerDir := filepath.Join(b.tmpDir, "latest-layer")
+	if err := os.Mkdir(layerDir, 0755); err != nil {
+		return err
+	}
+	for _, path := range hidden {
+		whiteout := filepath.Join(layerDir, filepath.Dir(path), whiteoutPrefix+filepath.Base(path))
+		if err := os.MkdirAll(filepath.Dir(whiteout), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(whiteout, nil, 0644); err != nil {
+			return err
+		}
+	}
+	for _, bp := range b.metadata.Buildpacks {
+		if !bp.Latest {
+			continue
+		}
+		escapedID := (&buildpack.Buildpack{ID: bp.ID}).EscapedID()
+		if err := os.MkdirAll(filepath.Join(layerDir, escapedID), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(filepath.Join("/", "buildpacks", escapedID, bp.Version), filepath.Join(layerDir, escapedID, "latest")); err != nil {
+			return err
+		}
+	}
+
+	tarFile := filepath.Join(b.tmpDir, "latest.buildpacks.tar")
+	if err := archive.CreateTar(tarFile, layerDir, "/buildpacks", 0, 0); err != nil {
+		return errors.Wrap(err, "failed generate layer for latest links")
+	}
+	if err := b.image.AddLayer(tarFile); err != nil {
+		return errors.Wrap(err, "failed append latest link layer to image")
+	}
+	return nil
+}
+
+// save records the updated metadata and saves the builder under its new tag
+func (b *existingBuilder) save(flags ModifyBuilderFlags) (string, error) {
+	jsonBytes, err := json.Marshal(b.metadata)
+	if err != nil {
+		return "", errors.Wrap(err, "failed marshal builder image metadata")
+	}
+	if err := b.image.SetLabel(builder.MetadataLabel, string(jsonBytes)); err != nil {
+		return "", errors.Wrap(err, "failed to set metadata label")
+	}
+	if flags.Tag != "" {
+		b.image.Rename(flags.Tag)
+	}
+	if _, err := b.image.Save(); err != nil {
+		return "", err
+	}
+	return b.image.Name(), nil
+}
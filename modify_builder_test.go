@@ -0,0 +1,265 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestModifyBuilder(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("modifying builders is not implemented on windows")
+	}
+	spec.Run(t, "modify_builder", testModifyBuilder, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testModifyBuilder(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockFetcher    *mocks.MockFetcher
+		mockImage      *mocks.MockImage
+		factory        pack.BuilderFactory
+		savedLayers    map[string]*bytes.Buffer
+		labels         map[string]string
+		flags          pack.ModifyBuilderFlags
+		metadata       builder.Metadata
+		outBuf         bytes.Buffer
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		mockImage = mocks.NewMockImage(mockController)
+
+		packHome, err := ioutil.TempDir("", ".pack")
+		h.AssertNil(t, err)
+		h.ConfigurePackHome(t, packHome, "0000")
+		cfg, err := config.New(packHome)
+		h.AssertNil(t, err)
+
+		outBuf.Reset()
+		logger := logging.NewLogger(&outBuf, &outBuf, true, false)
+		factory = pack.BuilderFactory{
+			Logger:           logger,
+			Config:           cfg,
+			Fetcher:          mockFetcher,
+			BuildpackFetcher: buildpack.NewFetcher(logger, cfg.Path()),
+		}
+
+		metadata = builder.Metadata{
+			Buildpacks: []builder.BuildpackMetadata{
+				{ID: "some.bp1", Version: "1.2.3", Latest: true, Stacks: []string{"com.example.stack"}},
+				{ID: "some/bp2", Version: "1.2.4", Stacks: []string{"com.example.stack"}},
+			},
+			Groups: []builder.GroupMetadata{
+				{Buildpacks: []builder.BuildpackMetadata{{ID: "some.bp1", Version: "1.2.3"}}},
+			},
+		}
+
+		savedLayers = map[string]*bytes.Buffer{}
+		labels = map[string]string{}
+		mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(mockImage, nil)
+		mockImage.EXPECT().Found().Return(true, nil)
+		mockImage.EXPECT().Name().Return("some/builder").AnyTimes()
+		mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("com.example.stack", nil).AnyTimes()
+		mockImage.EXPECT().Label(builder.MetadataLabel).DoAndReturn(func(string) (string, error) {
+			label, err := json.Marshal(metadata)
+			return string(label), err
+		}).AnyTimes()
+		mockImage.EXPECT().AddLayer(gomock.Any()).Do(func(layerPath string) {
+			contents, err := ioutil.ReadFile(layerPath)
+			h.AssertNil(t, err)
+			savedLayers[filepath.Base(layerPath)] = bytes.NewBuffer(contents)
+		}).AnyTimes()
+		mockImage.EXPECT().SetLabel(gomock.Any(), gomock.Any()).Do(func(name, value string) {
+			labels[name] = value
+		}).AnyTimes()
+
+		flags = pack.ModifyBuilderFlags{BuilderName: "some/builder", Tag: "some/new-builder", NoPull: true}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	expectSave := func() {
+		mockImage.EXPECT().Rename("some/new-builder")
+		mockImage.EXPECT().Save()
+	}
+
+	savedMetadata := func() builder.Metadata {
+		var metadata builder.Metadata
+		h.AssertNil(t, json.Unmarshal([]byte(labels[builder.MetadataLabel]), &metadata))
+		return metadata
+	}
+
+	when("#AddBuildpack", func() {
+		it("appends a layer for the buildpack and records it as latest", func() {
+			expectSave()
+			bpDir, err := filepath.Abs(filepath.Join("testdata", "some-latest-path-2"))
+			h.AssertNil(t, err)
+
+			_, err = factory.AddBuildpack(context.TODO(), pack.AddBuildpackFlags{
+				ModifyBuilderFlags: flags,
+				BuildpackURI:       bpDir,
+				Latest:             true,
+			})
+			h.AssertNil(t, err)
+
+			_, exists := savedLayers["some_bp2.1.2.5.tar"]
+			h.AssertEq(t, exists, true)
+			_, exists = savedLayers["latest.buildpacks.tar"]
+			h.AssertEq(t, exists, true)
+
+			h.AssertEq(t, savedMetadata().Buildpacks, []builder.BuildpackMetadata{
+				{ID: "some.bp1", Version: "1.2.3", Latest: true, Stacks: []string{"com.example.stack"}},
+				{ID: "some/bp2", Version: "1.2.4", Stacks: []string{"com.example.stack"}},
+				{ID: "some/bp2", Version: "1.2.5", Latest: true, Stacks: []string{"com.example.stack"}},
+			})
+		})
+
+		it("fails when the builder already has that version of the buildpack", func() {
+			bpDir, err := filepath.Abs(filepath.Join("testdata", "some-path-2"))
+			h.AssertNil(t, err)
+
+			_, err = factory.AddBuildpack(context.TODO(), pack.AddBuildpackFlags{ModifyBuilderFlags: flags, BuildpackURI: bpDir})
+			h.AssertError(t, err, "buildpack 'some/bp2@1.2.4' is already in builder 'some/builder'")
+		})
+	})
+
+	when("#RemoveBuildpack", func() {
+		it("hides the buildpack with a whiteout and removes it from the metadata", func() {
+			expectSave()
+
+			_, err := factory.RemoveBuildpack(context.TODO(), pack.RemoveBuildpackFlags{ModifyBuilderFlags: flags, Buildpack: "some/bp2@1.2.4"})
+			h.AssertNil(t, err)
+
+			layer, exists := savedLayers["latest.buildpacks.tar"]
+			h.AssertEq(t, exists, true)
+			_, err = h.UntarSingleFile(layer, "/buildpacks/.wh.some_bp2")
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, savedMetadata().Buildpacks, []builder.BuildpackMetadata{
+				{ID: "some.bp1", Version: "1.2.3", Latest: true, Stacks: []string{"com.example.stack"}},
+			})
+		})
+
+		it("warns when it removes the latest version of a buildpack with other versions", func() {
+			expectSave()
+			metadata.Buildpacks = append(metadata.Buildpacks, builder.BuildpackMetadata{ID: "some/bp2", Version: "1.2.5", Latest: true})
+
+			_, err := factory.RemoveBuildpack(context.TODO(), pack.RemoveBuildpackFlags{ModifyBuilderFlags: flags, Buildpack: "some/bp2@1.2.5"})
+			h.AssertNil(t, err)
+
+			layer, exists := savedLayers["latest.buildpacks.tar"]
+			h.AssertEq(t, exists, true)
+			_, err = h.UntarSingleFile(layer, "/buildpacks/some_bp2/.wh.latest")
+			h.AssertNil(t, err)
+			h.AssertContains(t, outBuf.String(), "Warning: no version of buildpack 'some/bp2' is marked latest anymore")
+		})
+
+		it("refuses to remove a buildpack that a group uses", func() {
+			_, err := factory.RemoveBuildpack(context.TODO(), pack.RemoveBuildpackFlags{ModifyBuilderFlags: flags, Buildpack: "some.bp1"})
+			h.AssertError(t, err, "buildpack 'some.bp1@1.2.3' is used by group 1 of builder 'some/builder'")
+		})
+	})
+
+	when("#SetOrder", func() {
+		var orderPath string
+
+		it.Before(func() {
+			file, err := ioutil.TempFile("", "order.toml")
+			h.AssertNil(t, err)
+			orderPath = file.Name()
+			file.Close()
+		})
+
+		it.After(func() {
+			os.Remove(orderPath)
+		})
+
+		it("replaces the order.toml layer and the groups in the metadata", func() {
+			expectSave()
+			h.AssertNil(t, ioutil.WriteFile(orderPath, []byte(`[[groups]]
+buildpacks = [{ id = "some/bp2", version = "^1" }, { id = "some.bp1", version = "latest" }]
+`), 0644))
+
+			_, err := factory.SetOrder(context.TODO(), pack.SetOrderFlags{ModifyBuilderFlags: flags, OrderPath: orderPath})
+			h.AssertNil(t, err)
+
+			layer, exists := savedLayers["order.tar"]
+			h.AssertEq(t, exists, true)
+			contents, err := h.UntarSingleFile(layer, "/buildpacks/order.toml")
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(contents), `version = "1.2.4"`)
+
+			h.AssertEq(t, savedMetadata().Groups, []builder.GroupMetadata{
+				{Buildpacks: []builder.BuildpackMetadata{{ID: "some/bp2", Version: "1.2.4"}, {ID: "some.bp1", Version: "latest"}}},
+			})
+		})
+
+		it("fails when a group refers to a buildpack the builder does not have", func() {
+			h.AssertNil(t, ioutil.WriteFile(orderPath, []byte(`[[groups]]
+buildpacks = [{ id = "missing.bp", version = "1.0.0" }]
+`), 0644))
+
+			_, err := factory.SetOrder(context.TODO(), pack.SetOrderFlags{ModifyBuilderFlags: flags, OrderPath: orderPath})
+			h.AssertError(t, err, "line 2: group 1: buildpack 'missing.bp@1.0.0' is not declared in [[buildpacks]]")
+		})
+
+		it("uses the latest version and keeps optional entries", func() {
+			expectSave()
+			h.AssertNil(t, ioutil.WriteFile(orderPath, []byte(`[[groups]]
+buildpacks = [{ id = "some.bp1" }, { id = "some/bp2", version = "1.2.4", optional = true }]
+`), 0644))
+
+			_, err := factory.SetOrder(context.TODO(), pack.SetOrderFlags{ModifyBuilderFlags: flags, OrderPath: orderPath})
+			h.AssertNil(t, err)
+
+			contents, err := h.UntarSingleFile(savedLayers["order.tar"], "/buildpacks/order.toml")
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(contents), `version = "latest"`)
+			h.AssertContains(t, string(contents), `optional = true`)
+		})
+
+		it("fails for an order that is not valid", func() {
+			h.AssertNil(t, ioutil.WriteFile(orderPath, []byte(`[[groups]]
+buildpacks = []
+`), 0644))
+
+			_, err := factory.SetOrder(context.TODO(), pack.SetOrderFlags{ModifyBuilderFlags: flags, OrderPath: orderPath})
+			h.AssertError(t, err, "group 1 has no buildpacks")
+		})
+
+		it("fails for a buildpack given by path", func() {
+			bpDir, err := filepath.Abs(filepath.Join("testdata", "some-path-2"))
+			h.AssertNil(t, err)
+			h.AssertNil(t, ioutil.WriteFile(orderPath, []byte(fmt.Sprintf(`[[groups]]
+buildpacks = [{ path = %q }]
+`, bpDir)), 0644))
+
+			_, err = factory.SetOrder(context.TODO(), pack.SetOrderFlags{ModifyBuilderFlags: flags, OrderPath: orderPath})
+			h.AssertError(t, err, "a builder order can only refer to buildpacks in the builder")
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package pack
+
+import (
+	"encoding/json"
+
+	"github.com/buildpack/lifecycle"
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+type RunImageStatus struct {
+	Image         string
+	RunImage      string
+	CurrentDigest string
+	LatestDigest  string
+	Outdated      bool
+}
+
+func (c *Client) CheckRunImage(name string, daemon bool) (*RunImageStatus, error) {
+	var (
+		appImage image.Image
+		err      error
+	)
+
+	if daemon {
+		appImage, err = c.fetcher.FetchLocalImage(name)
+	} else {
+		appImage, err = c.fetcher.FetchRemoteImage(name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get app image %s", style.Symbol(name))
+	}
+
+	if found, err := appImage.Found(); err != nil {
+		return nil, errors.Wrapf(err, "failed to find app image %s", style.Symbol(name))
+	} else if !found {
+		return nil, nil
+	}
+
+	label, err := appImage.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for app image %s", style.Symbol(name))
+	}
+	if label == "" {
+		return nil, errors.Errorf("app image %s missing label %s", style.Symbol(name), style.Symbol(lifecycle.MetadataLabel))
+	}
+
+	var metadata lifecycle.AppImageMetadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse metadata for app image %s", style.Symbol(name))
+	}
+
+	runImageName, err := c.runImageForApp(name, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// The latest run image always lives in a registry, even when the app image is local
+	runImage, err := c.fetcher.FetchRemoteImage(runImageName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get run image %s", style.Symbol(runImageName))
+	}
+	if found, err := runImage.Found(); err != nil {
+		return nil, errors.Wrapf(err, "failed to find run image %s", style.Symbol(runImageName))
+	} else if !found {
+		return nil, errors.Errorf("run image %s does not exist", style.Symbol(runImageName))
+	}
+
+	latestDigest, err := runImage.Digest()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get digest of run image %s", style.Symbol(runImageName))
+	}
+
+	outdated := false
+	if metadata.RunImage.SHA != "" {
+		outdated = metadata.RunImage.SHA != latestDigest
+	} else {
+		// Images exported to a daemon from an unpulled run image have no digest recorded
+		topLayer, err := runImage.TopLayer()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get top layer of run image %s", style.Symbol(runImageName))
+		}
+		outdated = metadata.RunImage.TopLayer != topLayer
+	}
+
+	return &RunImageStatus{
+		Image:         name,
+		RunImage:      runImageName,
+		CurrentDigest: metadata.RunImage.SHA,
+		LatestDigest:  latestDigest,
+		Outdated:      outdated,
+	}, nil
+}
+
+func (c *Client) runImageForApp(appName string, metadata lifecycle.AppImageMetadata) (string, error) {
+	if metadata.Stack.RunImage.Image == "" {
+		return "", errors.Errorf("app image %s does not specify a run image", style.Symbol(appName))
+	}
+	return runImageForRegistry(c.config, appName, metadata)
+}
@@ -0,0 +1,142 @@
+package pack_test
+
+import (
+	"errors"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestOutdated(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Outdated", testOutdated, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testOutdated(t *testing.T, when spec.G, it spec.S) {
+	var (
+		client         *pack.Client
+		mockFetcher    *mocks.MockFetcher
+		mockController *gomock.Controller
+		appImage       *imgtest.FakeImage
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		client = pack.NewClient(&config.Config{
+			RunImages: []config.RunImage{
+				{Image: "some/run-image", Mirrors: []string{"example.com/local/run-image"}},
+			},
+		}, mockFetcher)
+		appImage = imgtest.NewFakeImage(t, "some/app", "", "")
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#CheckRunImage", func() {
+		when("the app image has run image metadata", func() {
+			it.Before(func() {
+				h.AssertNil(t, appImage.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "runImage": {"topLayer": "sha256:old-top-layer", "sha": "sha256:old-digest"},
+  "stack": {"runImage": {"image": "some/run-image", "mirrors": ["gcr.io/some/run-image"]}}
+}`))
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage, nil)
+			})
+
+			it("reports an image on an older run image as outdated", func() {
+				runImage := imgtest.NewFakeImage(t, "some/run-image", "sha256:new-top-layer", "sha256:new-digest")
+				mockFetcher.EXPECT().FetchRemoteImage("some/run-image").Return(runImage, nil)
+
+				status, err := client.CheckRunImage("some/app", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, status, &pack.RunImageStatus{
+					Image:         "some/app",
+					RunImage:      "some/run-image",
+					CurrentDigest: "sha256:old-digest",
+					LatestDigest:  "sha256:new-digest",
+					Outdated:      true,
+				})
+			})
+
+			it("reports an image on the latest run image as up to date", func() {
+				runImage := imgtest.NewFakeImage(t, "some/run-image", "sha256:old-top-layer", "sha256:old-digest")
+				mockFetcher.EXPECT().FetchRemoteImage("some/run-image").Return(runImage, nil)
+
+				status, err := client.CheckRunImage("some/app", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.Outdated, false)
+			})
+
+			it("returns an error when the run image cannot be fetched", func() {
+				mockFetcher.EXPECT().FetchRemoteImage("some/run-image").Return(nil, errors.New("some-error"))
+
+				_, err := client.CheckRunImage("some/app", true)
+				h.AssertError(t, err, "failed to get run image 'some/run-image': some-error")
+			})
+		})
+
+		when("the app image is in another registry", func() {
+			it("uses the run image mirror in the same registry", func() {
+				h.AssertNil(t, appImage.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "runImage": {"topLayer": "sha256:old-top-layer", "sha": "sha256:old-digest"},
+  "stack": {"runImage": {"image": "some/run-image", "mirrors": ["gcr.io/some/run-image"]}}
+}`))
+				mockFetcher.EXPECT().FetchRemoteImage("example.com/some/app").Return(appImage, nil)
+				runImage := imgtest.NewFakeImage(t, "example.com/local/run-image", "", "sha256:old-digest")
+				mockFetcher.EXPECT().FetchRemoteImage("example.com/local/run-image").Return(runImage, nil)
+
+				status, err := client.CheckRunImage("example.com/some/app", false)
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.RunImage, "example.com/local/run-image")
+				h.AssertEq(t, status.Outdated, false)
+			})
+		})
+
+		when("the app image has no run image digest", func() {
+			it("compares the run image top layer", func() {
+				h.AssertNil(t, appImage.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "runImage": {"topLayer": "sha256:old-top-layer"},
+  "stack": {"runImage": {"image": "some/run-image"}}
+}`))
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage, nil)
+				runImage := imgtest.NewFakeImage(t, "some/run-image", "sha256:new-top-layer", "sha256:new-digest")
+				mockFetcher.EXPECT().FetchRemoteImage("some/run-image").Return(runImage, nil)
+
+				status, err := client.CheckRunImage("some/app", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.Outdated, true)
+			})
+		})
+
+		when("the app image is missing the metadata label", func() {
+			it("returns an error", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage, nil)
+
+				_, err := client.CheckRunImage("some/app", true)
+				h.AssertError(t, err, "app image 'some/app' missing label 'io.buildpacks.lifecycle.metadata'")
+			})
+		})
+
+		when("the app image does not exist", func() {
+			it("returns nil", func() {
+				h.AssertNil(t, appImage.Delete())
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage, nil)
+
+				status, err := client.CheckRunImage("some/app", true)
+				h.AssertNil(t, err)
+				h.AssertNil(t, status)
+			})
+		})
+	})
+}
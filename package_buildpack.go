@@ -0,0 +1,81 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+type BuildpackPackager struct {
+	Logger  *logging.Logger
+	Docker  Docker
+	Factory ImageFactory
+}
+
+type PackageBuildpackFlags struct {
+	BuildpackDir string
+	ArchivePath  string
+	ImageName    string
+	Publish      bool
+}
+
+// Package validates a buildpack directory and writes it as a reproducible .tgz archive, a buildpack image, or both.
+// Images are built in the daemon and pushed to their registry when publishing.
+func (p *BuildpackPackager) Package(ctx context.Context, flags PackageBuildpackFlags) (buildpack.Descriptor, error) {
+	if flags.ArchivePath == "" && flags.ImageName == "" {
+		return buildpack.Descriptor{}, errors.New("an archive path or an image name must be provided")
+	}
+	if flags.Publish && flags.ImageName == "" {
+		return buildpack.Descriptor{}, errors.New("an image name must be provided when publishing")
+	}
+
+	desc, err := buildpack.ReadDescriptor(flags.BuildpackDir)
+	if err != nil {
+		return buildpack.Descriptor{}, err
+	}
+
+	if flags.ArchivePath != "" {
+		p.Logger.Verbose("Writing archive %s", style.Symbol(flags.ArchivePath))
+		if _, err := buildpack.PackageArchive(flags.BuildpackDir, flags.ArchivePath); err != nil {
+			return buildpack.Descriptor{}, err
+		}
+	}
+
+	if flags.ImageName != "" {
+		if err := p.packageImage(ctx, flags); err != nil {
+			return buildpack.Descriptor{}, err
+		}
+	}
+	return desc, nil
+}
+
+func (p *BuildpackPackager) packageImage(ctx context.Context, flags PackageBuildpackFlags) error {
+	tmpDir, err := ioutil.TempDir("", "package-buildpack")
+	if err != nil {
+		return fmt.Errorf(`failed to create temporary directory: %s`, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	img := p.Factory.NewEmptyLocal(flags.ImageName)
+	if _, err := buildpack.PackageImage(img, flags.BuildpackDir, tmpDir); err != nil {
+		return err
+	}
+	if _, err := img.Save(); err != nil {
+		return errors.Wrapf(err, "failed to save image %s", style.Symbol(flags.ImageName))
+	}
+
+	if flags.Publish {
+		p.Logger.Verbose("Pushing image %s", style.Symbol(flags.ImageName))
+		if err := p.Docker.PushImage(ctx, flags.ImageName, p.Logger.RawVerboseWriter()); err != nil {
+			return errors.Wrapf(err, "failed to push image %s", style.Symbol(flags.ImageName))
+		}
+	}
+	return nil
+}
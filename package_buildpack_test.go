@@ -0,0 +1,144 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestPackageBuildpack(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "PackageBuildpack", testPackageBuildpack, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testPackageBuildpack(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockDocker     *mocks.MockDocker
+		mockFactory    *mocks.MockImageFactory
+		img            *imgtest.FakeImage
+		tmpDir         string
+		subject        *pack.BuildpackPackager
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = mocks.NewMockDocker(mockController)
+		mockFactory = mocks.NewMockImageFactory(mockController)
+		img = imgtest.NewFakeImage(t, "some/buildpack-image", "", "")
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "package-buildpack")
+		h.AssertNil(t, err)
+
+		var outBuf bytes.Buffer
+		subject = &pack.BuildpackPackager{
+			Logger:  logging.NewLogger(&outBuf, &outBuf, false, false),
+			Docker:  mockDocker,
+			Factory: mockFactory,
+		}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#Package", func() {
+		it("saves the buildpack image in the daemon", func() {
+			mockFactory.EXPECT().NewEmptyLocal("some/buildpack-image").Return(img)
+
+			desc, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ImageName:    "some/buildpack-image",
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, desc.Buildpack.ID, "some-buildpack-id")
+			h.AssertEq(t, desc.Buildpack.Version, "some-buildpack-version")
+			h.AssertEq(t, img.IsSaved(), true)
+
+			label, err := img.Label(buildpack.ImageMetadataLabel)
+			h.AssertNil(t, err)
+			h.AssertContains(t, label, `"id":"some-buildpack-id"`)
+		})
+
+		it("pushes the image when publishing", func() {
+			mockFactory.EXPECT().NewEmptyLocal("some/buildpack-image").Return(img)
+			mockDocker.EXPECT().PushImage(gomock.Any(), "some/buildpack-image", gomock.Any())
+
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ImageName:    "some/buildpack-image",
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+				Publish:      true,
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, img.IsSaved(), true)
+		})
+
+		it("reports a failed push", func() {
+			mockFactory.EXPECT().NewEmptyLocal("some/buildpack-image").Return(img)
+			mockDocker.EXPECT().PushImage(gomock.Any(), "some/buildpack-image", gomock.Any()).Return(errors.New("denied"))
+
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ImageName:    "some/buildpack-image",
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+				Publish:      true,
+			})
+			h.AssertError(t, err, "failed to push image 'some/buildpack-image': denied")
+		})
+
+		it("writes an archive without building an image", func() {
+			archiveFile := filepath.Join(tmpDir, "buildpack.tgz")
+
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ArchivePath:  archiveFile,
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+			})
+			h.AssertNil(t, err)
+
+			_, err = os.Stat(archiveFile)
+			h.AssertNil(t, err)
+		})
+
+		it("requires an archive or an image", func() {
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+			})
+			h.AssertError(t, err, "an archive path or an image name must be provided")
+		})
+
+		it("requires an image when publishing", func() {
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ArchivePath:  filepath.Join(tmpDir, "buildpack.tgz"),
+				BuildpackDir: filepath.Join("testdata", "buildpack"),
+				Publish:      true,
+			})
+			h.AssertError(t, err, "an image name must be provided when publishing")
+		})
+
+		it("does not save the image when the buildpack is invalid", func() {
+			_, err := subject.Package(context.TODO(), pack.PackageBuildpackFlags{
+				ImageName:    "some/buildpack-image",
+				BuildpackDir: filepath.Join("testdata", "no-such-buildpack"),
+			})
+			h.AssertError(t, err, "reading buildpack.toml")
+			h.AssertEq(t, img.IsSaved(), false)
+		})
+	})
+}
@@ -16,6 +16,7 @@ import (
 type RebaseConfig struct {
 	Image        image.Image
 	NewBaseImage image.Image
+	Tag          string
 }
 
 type RebaseFactory struct {
@@ -29,9 +30,19 @@ type RebaseFlags struct {
 	Publish  bool
 	NoPull   bool
 	RunImage string
+	Tag      string
 }
 
 func (f *RebaseFactory) RebaseConfigFromFlags(ctx context.Context, flags RebaseFlags) (RebaseConfig, error) {
+	// The rebased image is saved under the destination name, so mirrors are chosen by its registry
+	destName := flags.RepoName
+	if flags.Tag != "" {
+		if _, err := config.Registry(flags.Tag); err != nil {
+			return RebaseConfig{}, errors.Wrapf(err, "invalid tag '%s'", flags.Tag)
+		}
+		destName = flags.Tag
+	}
+
 	var newImageFn func(string) (image.Image, error)
 	if flags.Publish {
 		newImageFn = f.Fetcher.FetchRemoteImage
@@ -66,20 +77,9 @@ func (f *RebaseFactory) RebaseConfigFromFlags(ctx context.Context, flags RebaseF
 			return RebaseConfig{}, err
 		}
 
-		registry, err := config.Registry(flags.RepoName)
+		runImageName, err = runImageForRegistry(f.Config, destName, appImageMetadata)
 		if err != nil {
-			return RebaseConfig{}, errors.Wrapf(err, "parsing registry from reference '%s'", flags.RepoName)
-		}
-
-		mirrors := make([]string, 0)
-		if localRunImage := f.Config.GetRunImage(appImageMetadata.Stack.RunImage.Image); localRunImage != nil {
-			mirrors = append(mirrors, localRunImage.Mirrors...)
-		}
-		mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Image)
-		mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Mirrors...)
-		runImageName, err = config.ImageByRegistry(registry, mirrors)
-		if err != nil {
-			return RebaseConfig{}, errors.Wrapf(err, "find image by registry")
+			return RebaseConfig{}, err
 		}
 	}
 
@@ -95,9 +95,31 @@ func (f *RebaseFactory) RebaseConfigFromFlags(ctx context.Context, flags RebaseF
 	return RebaseConfig{
 		Image:        appImage,
 		NewBaseImage: baseImage,
+		Tag:          flags.Tag,
 	}, nil
 }
 
+// runImageForRegistry picks the run image of an app, or one of its mirrors, that is on the registry of imageName.
+// Locally configured mirrors are preferred.
+func runImageForRegistry(cfg *config.Config, imageName string, metadata lifecycle.AppImageMetadata) (string, error) {
+	registry, err := config.Registry(imageName)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing registry from reference '%s'", imageName)
+	}
+
+	mirrors := make([]string, 0)
+	if localRunImage := cfg.GetRunImage(metadata.Stack.RunImage.Image); localRunImage != nil {
+		mirrors = append(mirrors, localRunImage.Mirrors...)
+	}
+	mirrors = append(mirrors, metadata.Stack.RunImage.Image)
+	mirrors = append(mirrors, metadata.Stack.RunImage.Mirrors...)
+	runImageName, err := config.ImageByRegistry(registry, mirrors)
+	if err != nil {
+		return "", errors.Wrapf(err, "find image by registry")
+	}
+	return runImageName, nil
+}
+
 func (f *RebaseFactory) Rebase(cfg RebaseConfig) error {
 	label, err := cfg.Image.Label("io.buildpacks.lifecycle.metadata")
 	if err != nil {
@@ -125,6 +147,11 @@ func (f *RebaseFactory) Rebase(cfg RebaseConfig) error {
 		return err
 	}
 
+	if cfg.Tag != "" {
+		f.Logger.Info("Saving rebased image as %s", style.Symbol(cfg.Tag))
+		cfg.Image.Rename(cfg.Tag)
+	}
+
 	sha, err := cfg.Image.Save()
 	if err != nil {
 		return err
@@ -160,6 +160,38 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 
+				when("a tag in another registry is provided", func() {
+					it("chooses the mirror in the registry of the tag", func() {
+						mockBaseImage := mocks.NewMockImage(mockController)
+						mockBaseImage.EXPECT().Name().Return("some/base-image").AnyTimes()
+						mockImage := mocks.NewMockImage(mockController)
+						mockImage.EXPECT().Name().Return("some/name").AnyTimes()
+						mockFetcher.EXPECT().FetchRemoteImage("myorg/myrepo").Return(mockImage, nil)
+						mockFetcher.EXPECT().FetchRemoteImage("example.com/run/image").Return(mockBaseImage, nil)
+						mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+							Return(`{"stack":{"runImage":{"image":"some/other/runimage", "mirrors":["example.com/run/image"]}}}`, nil).AnyTimes()
+
+						rc, err := factory.RebaseConfigFromFlags(context.TODO(), pack.RebaseFlags{
+							RepoName: "myorg/myrepo",
+							Tag:      "example.com/myorg/myrepo:patched",
+							Publish:  true,
+						})
+						h.AssertNil(t, err)
+						h.AssertSameInstance(t, rc.NewBaseImage, mockBaseImage)
+						h.AssertEq(t, rc.Tag, "example.com/myorg/myrepo:patched")
+					})
+				})
+
+				when("the tag is not a valid image reference", func() {
+					it("returns an error", func() {
+						_, err := factory.RebaseConfigFromFlags(context.TODO(), pack.RebaseFlags{
+							RepoName: "myorg/myrepo",
+							Tag:      "Invalid:Tag:Name",
+						})
+						h.AssertError(t, err, "invalid tag 'Invalid:Tag:Name'")
+					})
+				})
+
 				when("the image does not have a label with a run image specified", func() {
 					it("returns an error", func() {
 						mockImage := mocks.NewMockImage(mockController)
@@ -276,6 +308,28 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 				err := factory.Rebase(rebaseConfig)
 				h.AssertNil(t, err)
 			})
+
+			it("saves the rebased image under the tag when one is provided", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockBaseImage.EXPECT().Name().Return("some/base-image").AnyTimes()
+				mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil)
+				mockBaseImage.EXPECT().Digest().Return("some-sha", nil)
+				mockImage := mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Name().Return("some/name").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+					Return(`{"runimage":{"topLayer":"old-top-layer"}, "app":{"sha":"data"}}`, nil)
+				mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+				setLabel := mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any())
+				rename := mockImage.EXPECT().Rename("some/name:patched").After(setLabel)
+				mockImage.EXPECT().Save().After(rename).Return("some-digest", nil)
+
+				err := factory.Rebase(pack.RebaseConfig{
+					Image:        mockImage,
+					NewBaseImage: mockBaseImage,
+					Tag:          "some/name:patched",
+				})
+				h.AssertNil(t, err)
+			})
 		})
 	})
 }
@@ -0,0 +1,166 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	IDLabel     = "io.buildpacks.stack.id"
+	MixinsLabel = "io.buildpacks.stack.mixins"
+
+	DefaultUserName = "cnb"
+	DefaultUserID   = 1000
+	DefaultGroupID  = 1000
+)
+
+// Descriptor is the content of a stack.toml, which describes a build image and a run image made from base images
+type Descriptor struct {
+	ID        string   `toml:"id"`
+	BaseImage string   `toml:"base-image"`
+	Mixins    []string `toml:"mixins"`
+	User      User     `toml:"user"`
+	Build     Image    `toml:"build"`
+	Run       Image    `toml:"run"`
+}
+
+// User is the user that buildpacks and apps run as
+type User struct {
+	Name string `toml:"name"`
+	UID  int    `toml:"uid"`
+	GID  int    `toml:"gid"`
+}
+
+// Image is the build or run image of a stack. BaseImage and Mixins default to, and add to, those of the stack.
+type Image struct {
+	Image     string   `toml:"image"`
+	BaseImage string   `toml:"base-image"`
+	Mixins    []string `toml:"mixins"`
+}
+
+// ReadDescriptor reads and validates a stack.toml, filling in the default user
+func ReadDescriptor(path string) (Descriptor, error) {
+	var desc Descriptor
+	if _, err := toml.DecodeFile(path, &desc); err != nil {
+		return Descriptor{}, errors.Wrapf(err, "reading stack config %s", style.Symbol(path))
+	}
+	if desc.User.Name == "" {
+		desc.User.Name = DefaultUserName
+	}
+	if desc.User.UID == 0 {
+		desc.User.UID = DefaultUserID
+	}
+	if desc.User.GID == 0 {
+		desc.User.GID = DefaultGroupID
+	}
+	if err := desc.Validate(); err != nil {
+		return Descriptor{}, errors.Wrapf(err, "invalid stack config %s", style.Symbol(path))
+	}
+	return desc, nil
+}
+
+// userNamePattern matches the user names that are safe to create in the build and run images
+var userNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+
+func (d Descriptor) Validate() error {
+	if d.ID == "" {
+		return fmt.Errorf("%s is required", style.Symbol("id"))
+	}
+	if d.User.UID <= 0 || d.User.GID <= 0 {
+		return fmt.Errorf("%s and %s must be positive", style.Symbol("user.uid"), style.Symbol("user.gid"))
+	}
+	if !userNamePattern.MatchString(d.User.Name) {
+		return fmt.Errorf("%s must match %s", style.Symbol("user.name"), style.Symbol(userNamePattern.String()))
+	}
+	for i, img := range []Image{d.Build, d.Run} {
+		table := []string{"build", "run"}[i]
+		if img.Image == "" {
+			return fmt.Errorf("%s is required", style.Symbol(table+".image"))
+		}
+		if d.BaseImageFor(img) == "" {
+			return fmt.Errorf("%s or %s is required", style.Symbol("base-image"), style.Symbol(table+".base-image"))
+		}
+	}
+	if d.Build.Image == d.Run.Image {
+		return fmt.Errorf("%s and %s must be different", style.Symbol("build.image"), style.Symbol("run.image"))
+	}
+	return nil
+}
+
+// BaseImageFor returns the base image of the build or run image
+func (d Descriptor) BaseImageFor(img Image) string {
+	if img.BaseImage != "" {
+		return img.BaseImage
+	}
+	return d.BaseImage
+}
+
+// MixinsFor returns the mixins of the stack followed by those of the build or run image
+func (d Descriptor) MixinsFor(img Image) []string {
+	mixins := append([]string{}, d.Mixins...)
+	for _, mixin := range img.Mixins {
+		if !contains(mixins, mixin) {
+			mixins = append(mixins, mixin)
+		}
+	}
+	return mixins
+}
+
+// Dockerfile returns a Dockerfile that turns the base image of img into a stack image. It adds the stack user, sets
+// CNB_USER_ID and CNB_GROUP_ID, which pack reads to own the files it gives to the lifecycle, and labels the image with
+// the stack ID and mixins.
+func (d Descriptor) Dockerfile(img Image) (string, error) {
+	labels := []string{IDLabel + "=" + strconv.Quote(d.ID)}
+	if mixins := d.MixinsFor(img); len(mixins) > 0 {
+		mixinsJSON, err := json.Marshal(mixins)
+		if err != nil {
+			return "", err
+		}
+		labels = append(labels, MixinsLabel+"="+strconv.Quote(string(mixinsJSON)))
+	}
+
+	uid, gid := d.User.UID, d.User.GID
+	lines := []string{
+		"FROM " + d.BaseImageFor(img),
+		"USER root",
+		"RUN " + d.User.script(),
+		fmt.Sprintf("ENV CNB_USER_ID=%d CNB_GROUP_ID=%d", uid, gid),
+		"LABEL " + strings.Join(labels, " "),
+		fmt.Sprintf("USER %d:%d", uid, gid),
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// script returns a shell script that gives the image a group with the GID and a user with the UID. A group or user that
+// the base image already has is reused, whatever its name, as only the IDs matter to pack. The script fails when the
+// existing user has a primary group other than the GID.
+func (u User) script() string {
+	return strings.Join([]string{
+		fmt.Sprintf("if ! getent group %[2]d >/dev/null; then"+
+			" groupadd --gid %[2]d %[3]s || addgroup -g %[2]d %[3]s;"+
+			" fi", u.UID, u.GID, u.Name),
+		fmt.Sprintf("if ! getent passwd %[1]d >/dev/null; then"+
+			" useradd --uid %[1]d --gid %[2]d --create-home --shell /bin/sh %[3]s ||"+
+			` adduser -D -u %[1]d -G "$(getent group %[2]d | cut -d: -f1)" -s /bin/sh %[3]s;`+
+			` elif [ "$(getent passwd %[1]d | cut -d: -f4)" != %[2]d ]; then`+
+			` echo "user %[1]d of the base image has a primary group other than %[2]d" >&2 && exit 1;`+
+			" fi", u.UID, u.GID, u.Name),
+	}, " && \\\n    ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
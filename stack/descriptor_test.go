@@ -0,0 +1,203 @@
+package stack_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/stack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestDescriptor(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Descriptor", testDescriptor, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDescriptor(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "stack-descriptor")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	readDescriptor := func(contents string) (stack.Descriptor, error) {
+		path := filepath.Join(tmpDir, "stack.toml")
+		h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return stack.ReadDescriptor(path)
+	}
+
+	when("#ReadDescriptor", func() {
+		it("defaults the user", func() {
+			desc, err := readDescriptor(`
+id = "some.stack"
+base-image = "some/base"
+build = { image = "some/build" }
+run = { image = "some/run" }
+`)
+			h.AssertNil(t, err)
+			h.AssertEq(t, desc.User, stack.User{Name: "cnb", UID: 1000, GID: 1000})
+		})
+
+		it("requires an id", func() {
+			_, err := readDescriptor(`
+base-image = "some/base"
+build = { image = "some/build" }
+run = { image = "some/run" }
+`)
+			h.AssertError(t, err, "'id' is required")
+		})
+
+		it("requires a base image for each image", func() {
+			_, err := readDescriptor(`
+id = "some.stack"
+build = { image = "some/build", base-image = "some/base" }
+run = { image = "some/run" }
+`)
+			h.AssertError(t, err, "'base-image' or 'run.base-image' is required")
+		})
+
+		it("requires different build and run images", func() {
+			_, err := readDescriptor(`
+id = "some.stack"
+base-image = "some/base"
+build = { image = "some/stack" }
+run = { image = "some/stack" }
+`)
+			h.AssertError(t, err, "'build.image' and 'run.image' must be different")
+		})
+
+		it("rejects a user name that is unsafe to use in a shell", func() {
+			_, err := readDescriptor(`
+id = "some.stack"
+base-image = "some/base"
+user = { name = "cnb; rm -rf /" }
+build = { image = "some/build" }
+run = { image = "some/run" }
+`)
+			h.AssertError(t, err, "'user.name' must match '^[a-z_][a-z0-9_-]*$'")
+		})
+	})
+
+	when("#Dockerfile", func() {
+		it("adds the user, env and labels to the base image", func() {
+			desc := stack.Descriptor{
+				ID:        "some.stack",
+				BaseImage: "some/base",
+				Mixins:    []string{"curl"},
+				User:      stack.User{Name: "some-user", UID: 1234, GID: 2345},
+				Build:     stack.Image{Image: "some/build", Mixins: []string{"build-essential", "curl"}},
+				Run:       stack.Image{Image: "some/run", BaseImage: "some/run-base"},
+			}
+
+			dockerfile, err := desc.Dockerfile(desc.Build)
+			h.AssertNil(t, err)
+			h.AssertEq(t, dockerfile, `FROM some/base
+USER root
+RUN if ! getent group 2345 >/dev/null; then groupadd --gid 2345 some-user || addgroup -g 2345 some-user; fi && \
+    if ! getent passwd 1234 >/dev/null; then useradd --uid 1234 --gid 2345 --create-home --shell /bin/sh some-user || adduser -D -u 1234 -G "$(getent group 2345 | cut -d: -f1)" -s /bin/sh some-user; elif [ "$(getent passwd 1234 | cut -d: -f4)" != 2345 ]; then echo "user 1234 of the base image has a primary group other than 2345" >&2 && exit 1; fi
+ENV CNB_USER_ID=1234 CNB_GROUP_ID=2345
+LABEL io.buildpacks.stack.id="some.stack" io.buildpacks.stack.mixins="[\"curl\",\"build-essential\"]"
+USER 1234:2345
+`)
+
+			dockerfile, err = desc.Dockerfile(desc.Run)
+			h.AssertNil(t, err)
+			h.AssertContains(t, dockerfile, "FROM some/run-base\n")
+			h.AssertContains(t, dockerfile, `LABEL io.buildpacks.stack.id="some.stack" io.buildpacks.stack.mixins="[\"curl\"]"`)
+		})
+
+		when("the base image already has the user or group", func() {
+			var binDir, etcDir, logPath string
+
+			// runUserScript runs the RUN instruction of the Dockerfile against a fake /etc, with stubs that log which
+			// users and groups are created
+			runUserScript := func(passwd, group string) (string, error) {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(etcDir, "passwd"), []byte(passwd), 0644))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(etcDir, "group"), []byte(group), 0644))
+
+				desc := stack.Descriptor{
+					ID:        "some.stack",
+					BaseImage: "some/base",
+					User:      stack.User{Name: "cnb", UID: 1000, GID: 1000},
+					Build:     stack.Image{Image: "some/build"},
+					Run:       stack.Image{Image: "some/run"},
+				}
+				dockerfile, err := desc.Dockerfile(desc.Build)
+				h.AssertNil(t, err)
+				dockerfile = strings.Replace(dockerfile, "\\\n", "", -1)
+				var script string
+				for _, line := range strings.Split(dockerfile, "\n") {
+					if strings.HasPrefix(line, "RUN ") {
+						script = strings.TrimPrefix(line, "RUN ")
+					}
+				}
+
+				cmd := exec.Command("sh", "-c", script)
+				cmd.Env = []string{"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH"), "FAKE_ETC=" + etcDir, "STUB_LOG=" + logPath}
+				out, err := cmd.CombinedOutput()
+				calls, _ := ioutil.ReadFile(logPath)
+				return string(calls) + string(out), err
+			}
+
+			it.Before(func() {
+				if runtime.GOOS == "windows" {
+					t.Skip("the user script is not run on windows")
+				}
+				binDir = filepath.Join(tmpDir, "bin")
+				etcDir = filepath.Join(tmpDir, "etc")
+				logPath = filepath.Join(tmpDir, "calls.log")
+				h.AssertNil(t, os.MkdirAll(binDir, 0755))
+				h.AssertNil(t, os.MkdirAll(etcDir, 0755))
+				stubs := map[string]string{
+					"getent":   `grep -E "^[^:]*:[^:]*:$2:" "$FAKE_ETC/$1"`,
+					"groupadd": `echo "groupadd $*" >> "$STUB_LOG"`,
+					"useradd":  `echo "useradd $*" >> "$STUB_LOG"`,
+				}
+				for name, body := range stubs {
+					h.AssertNil(t, ioutil.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+body+"\n"), 0755))
+				}
+			})
+
+			it("creates the user and group when neither exists", func() {
+				out, err := runUserScript("root:x:0:0::/root:/bin/sh\n", "root:x:0:\n")
+				h.AssertNil(t, err)
+				h.AssertContains(t, out, "groupadd --gid 1000 cnb")
+				h.AssertContains(t, out, "useradd --uid 1000 --gid 1000 --create-home --shell /bin/sh cnb")
+			})
+
+			it("reuses an existing user and group with the IDs", func() {
+				out, err := runUserScript("node:x:1000:1000::/home/node:/bin/sh\n", "node:x:1000:\n")
+				h.AssertNil(t, err)
+				h.AssertEq(t, out, "")
+			})
+
+			it("creates only the user when the group exists", func() {
+				out, err := runUserScript("root:x:0:0::/root:/bin/sh\n", "users:x:1000:\n")
+				h.AssertNil(t, err)
+				h.AssertNotContains(t, out, "groupadd")
+				h.AssertContains(t, out, "useradd --uid 1000 --gid 1000")
+			})
+
+			it("fails when the existing user has another primary group", func() {
+				out, err := runUserScript("node:x:1000:50::/home/node:/bin/sh\n", "node:x:1000:\n")
+				h.AssertNotNil(t, err)
+				h.AssertContains(t, out, "user 1000 of the base image has a primary group other than 1000")
+			})
+		})
+	})
+}
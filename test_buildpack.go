@@ -0,0 +1,108 @@
+package pack
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/build"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/style"
+)
+
+type TestBuildpackFlags struct {
+	BuildpackDir string
+	FixtureDir   string
+	// ExpectationsPath defaults to expectations.toml in the fixture
+	ExpectationsPath string
+	Builder          string
+	Env              []string
+	EnvFile          string
+	NoPull           bool
+}
+
+// TestBuildpack runs detection and, when it passes, the build of a buildpack against a fixture app and checks the
+// outcome against the expectations. Failed expectations are reported in the result rather than as an error.
+func (bf *BuildFactory) TestBuildpack(ctx context.Context, flags TestBuildpackFlags) (*buildpack.TestResult, error) {
+	bpDir, err := filepath.Abs(flags.BuildpackDir)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := buildpack.ReadDescriptor(bpDir)
+	if err != nil {
+		return nil, err
+	}
+	expectationsPath := flags.ExpectationsPath
+	if expectationsPath == "" {
+		expectationsPath = filepath.Join(flags.FixtureDir, "expectations.toml")
+	}
+	expectations, err := buildpack.ReadExpectations(expectationsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := bf.BuildConfigFromFlags(ctx, &BuildFlags{
+		AppDir:     flags.FixtureDir,
+		Builder:    flags.Builder,
+		Env:        flags.Env,
+		EnvFile:    flags.EnvFile,
+		NoPull:     flags.NoPull,
+		Buildpacks: []string{bpDir},
+	})
+	if err != nil {
+		return nil, err
+	}
+	lifecycle, err := build.NewLifecycle(b.LifecycleConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer lifecycle.Cleanup()
+
+	result := &buildpack.TestResult{
+		Buildpack: desc.Buildpack.ID + "@" + desc.Buildpack.Version,
+		Fixture:   flags.FixtureDir,
+	}
+
+	bf.Logger.Verbose(style.Step("DETECTING"))
+	var detectOut bytes.Buffer
+	detectPhase, err := lifecycle.NewDetect(build.WithOutput(&detectOut, &detectOut))
+	if err != nil {
+		return nil, err
+	}
+	defer detectPhase.Cleanup()
+	if err := result.RecordDetect(detectPhase.Run(ctx)); err != nil {
+		return nil, err
+	}
+	result.DetectOutput = detectOut.String()
+	lastPhase := detectPhase
+
+	if result.Detected {
+		bf.Logger.Verbose(style.Step("BUILDING"))
+		var buildOut bytes.Buffer
+		buildPhase, err := lifecycle.NewBuild(build.WithOutput(&buildOut, &buildOut))
+		if err != nil {
+			return nil, err
+		}
+		defer buildPhase.Cleanup()
+		if err := result.RecordBuild(buildPhase.Run(ctx)); err != nil {
+			return nil, err
+		}
+		result.BuildOutput = buildOut.String()
+		lastPhase = buildPhase
+	}
+
+	layers, err := lastPhase.Layers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer layers.Close()
+	bp := buildpack.Buildpack{ID: desc.Buildpack.ID}
+	if err := result.ReadLayers(layers, bp.EscapedID()); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the results of buildpack %s", style.Symbol(result.Buildpack))
+	}
+
+	expectations.Check(result)
+	return result, nil
+}